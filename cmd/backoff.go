@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/pkgforge-security/crt/repository"
+)
+
+// maxErrorBackoff caps the extra delay accumulated through -delay-on-error
+const maxErrorBackoff = 30 * time.Second
+
+// errorBackoff holds the extra delay (in ms) shared by all workers
+var errorBackoff atomic.Int64
+
+// recordFailure increases the shared backoff by -delay-on-error
+func recordFailure() {
+	if *delayOnError <= 0 {
+		return
+	}
+	for {
+		cur := errorBackoff.Load()
+		next := min(cur+int64(*delayOnError), maxErrorBackoff.Milliseconds())
+		if errorBackoff.CompareAndSwap(cur, next) {
+			return
+		}
+	}
+}
+
+// recordSuccess halves the shared backoff, decaying back to the baseline delay
+func recordSuccess() {
+	for {
+		cur := errorBackoff.Load()
+		if cur == 0 {
+			return
+		}
+		if errorBackoff.CompareAndSwap(cur, cur/2) {
+			return
+		}
+	}
+}
+
+// effectiveDelay returns the configured request delay plus any error backoff
+func effectiveDelay() time.Duration {
+	return time.Duration(int64(*requestDelay)+errorBackoff.Load()) * time.Millisecond
+}
+
+// retryDelay returns the pause before retry attempt (1 for the first retry):
+// the request delay doubled on every attempt, up to -retry-max-delay
+func retryDelay(attempt int) time.Duration {
+	delay := effectiveDelay()
+	for i := 1; i < attempt && delay < *retryMaxWait; i++ {
+		delay *= 2
+	}
+	return min(delay, *retryMaxWait)
+}
+
+// withJitter adds a random extra of up to fraction*delay to delay, so that
+// concurrent workers retrying at the same time don't stay synchronized
+func withJitter(delay time.Duration, fraction float64) time.Duration {
+	span := int64(float64(delay) * fraction)
+	if span <= 0 {
+		return delay
+	}
+	return delay + time.Duration(repository.Rand.Int63n(span))
+}
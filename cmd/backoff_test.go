@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestErrorBackoff(t *testing.T) {
+	tests := []struct {
+		name   string
+		delay  int // -d in ms
+		step   int // -delay-on-error in ms
+		events string
+		want   time.Duration
+	}{
+		{"disabled", 500, 0, "fff", 500 * time.Millisecond},
+		{"each failure adds", 500, 200, "fff", 1100 * time.Millisecond},
+		{"success halves", 500, 200, "ffs", 700 * time.Millisecond},
+		{"decays to the baseline", 500, 200, "fssssssss", 500 * time.Millisecond},
+		{"capped", 0, 20000, "ffff", maxErrorBackoff},
+	}
+
+	defer func(delay, step int) { *requestDelay, *delayOnError = delay, step }(*requestDelay, *delayOnError)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*requestDelay, *delayOnError = tt.delay, tt.step
+			errorBackoff.Store(0)
+			defer errorBackoff.Store(0)
+
+			for _, e := range tt.events {
+				if e == 'f' {
+					recordFailure()
+				} else {
+					recordSuccess()
+				}
+			}
+			if got := effectiveDelay(); got != tt.want {
+				t.Errorf("effectiveDelay() after %q = %s, want %s", tt.events, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithJitter(t *testing.T) {
+	tests := []struct {
+		name     string
+		delay    time.Duration
+		fraction float64
+		max      time.Duration
+	}{
+		{"no jitter", time.Second, 0, time.Second},
+		{"half", time.Second, 0.5, 1500 * time.Millisecond},
+		{"double", 200 * time.Millisecond, 1, 400 * time.Millisecond},
+		{"zero delay", 0, 0.5, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 1000 {
+				if got := withJitter(tt.delay, tt.fraction); got < tt.delay || got > tt.max {
+					t.Fatalf("withJitter(%s, %g) = %s, want between %s and %s", tt.delay, tt.fraction, got, tt.delay, tt.max)
+				}
+			}
+		})
+	}
+}
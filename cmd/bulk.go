@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkgforge-security/crt/result"
+)
+
+// errorsLog streams failed domains to -errors-file as "domain<TAB>error" lines
+type errorsLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// openErrorsFile opens -errors-file for writing; without it, Record is a no-op
+func openErrorsFile() (*errorsLog, error) {
+	if *errorsFile == "" {
+		return &errorsLog{}, nil
+	}
+	file, err := os.Create(*errorsFile)
+	if err != nil {
+		return nil, err
+	}
+	return &errorsLog{file: file}, nil
+}
+
+func (e *errorsLog) Record(domain string, err error) {
+	if e.file == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	msg := strings.ReplaceAll(err.Error(), "\n", " ")
+	fmt.Fprintf(e.file, "%s\t%s\n", domain, msg)
+}
+
+func (e *errorsLog) Close() error {
+	if e.file == nil {
+		return nil
+	}
+	return e.file.Close()
+}
+
+// isTimeout reports whether a lookup failed because a deadline was hit,
+// as opposed to any other failure
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	// Server-side statement_timeout cancellation (SQLSTATE 57014)
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "57014"
+}
+
+func performBulkLookup() {
+	// The CSV of every domain shares one header, so it needs the same columns
+	renderOpts.NRDColumn = true
+
+	if *uniqAcross {
+		seenNames = newNameSet()
+	}
+
+	// Read from the input file if given, otherwise from STDIN
+	input := os.Stdin
+	if *inputFile != "" {
+		file, err := os.Open(*inputFile)
+		if err != nil {
+			logFatal("Failed to open input file", "error", err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	// Read domains from the input
+	domains, overrides, err := readDomains(input)
+	if err != nil {
+		logFatal("Failed to read input file", "error", err)
+	}
+
+	// Subdomains and certificates don't share CSV columns, a merged table or a diff
+	if mixesModes(overrides) && (*csvOut || *tsvOut || *mergeOut || *diffFile != "" || *jsonLD) {
+		logFatal("Input lines overriding -s cannot be used with -csv, -tsv, -merge, -diff or -jsonld")
+	}
+
+	// Drop duplicate domains, so each is only queried once
+	if !*noDedupe {
+		var removed int
+		domains, removed = dedupeDomains(domains)
+		if removed > 0 {
+			logInfo("Removed duplicate Domains", "count", removed)
+		}
+	}
+
+	// Keep only this machine's partition of the input
+	if *shard != "" {
+		index, total, err := parseShard(*shard)
+		if err != nil {
+			logFatal("Invalid -shard", "error", err)
+		}
+		domains = shardDomains(domains, index, total)
+		logInfo("Sharded the Domains", "shard", fmt.Sprintf("%d/%d", index, total), "domains", len(domains))
+	}
+
+	// Skip the domains an earlier run already completed
+	resume, err = openCheckpoint()
+	if err != nil {
+		logFatal("Failed to open -resume file", "error", err)
+	}
+	defer resume.Close()
+	if pending := resume.Pending(domains); len(pending) < len(domains) {
+		logInfo("Resuming: skipping already completed Domains", "count", len(domains)-len(pending))
+		if len(pending) == 0 {
+			logInfo("All Domains were already completed", symbol("✅"))
+			return
+		}
+		domains = pending
+	}
+
+	if len(domains) == 0 {
+		logFatal("No domains found in input file")
+	}
+
+	// Clear output file if it's specified and not in JSONL or append mode
+	if *filename != "" && !*jsonlOut && !*jsonAppend && !*appendOut && stream == nil && !domainFiles {
+		if err := os.WriteFile(*filename, []byte{}, 0644); err != nil {
+			logFatal("Failed to clear output file", "error", err)
+		}
+	}
+
+	// Check for valid concurrency value
+	if *concurrent < 1 {
+		logWarn("Invalid concurrency value, setting it to 1", "concurrency", *concurrent)
+		*concurrent = 1
+	}
+
+	// Create a single repository connection
+	client := newClient()
+	defer client.Close()
+
+	// Process domains with limited concurrency
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, *concurrent)
+
+	// Count errors instead of buffering them, so memory doesn't grow with the input
+	var errCount, timeoutCount atomic.Int64
+	errLog, err := openErrorsFile()
+	if err != nil {
+		logFatal("Failed to open errors file", "error", err)
+	}
+	defer errLog.Close()
+
+	// Track processed domains for status updates
+	var processedCount int32
+	var processedMutex sync.Mutex
+	var dispatchedCount atomic.Int64 // Domains that got a semaphore slot
+	totalDomains := len(domains)
+
+	if !*quietMode && !*noProgress {
+		logInfo("Processing Domains", "domains", len(domains), "concurrency", *concurrent,
+			"delay", time.Duration(*requestDelay)*time.Millisecond, "retries", *retryCount, "limit", *limit)
+
+		// A terminal gets a single redrawn progress bar instead of a line per 10 domains
+		if stderrTerminal() && !*logJSON {
+			progress = newProgressBar(totalDomains)
+		}
+	}
+
+	// Keep input order in the output if requested
+	if *orderedOut {
+		ordered = newOrderedOutput()
+	}
+
+	for i, domain := range domains {
+		// Don't start new lookups if we're shutting down
+		if isShuttingDown() {
+			break
+		}
+
+		wg.Add(1)
+		semaphore <- struct{}{} // Acquire semaphore
+		dispatchedCount.Add(1)
+		go func(i int, d string) {
+			defer wg.Done()
+			defer func() { <-semaphore }() // Release semaphore
+
+			// Skip if we're shutting down
+			if isShuttingDown() {
+				return
+			}
+
+			var err error
+			if ordered != nil {
+				var res result.Printer
+				res, err = fetchResults(client, d, domainLimit(overrides, d), domainSubdomain(overrides, d))
+				ordered.Done(i, d, res, err)
+			} else {
+				err = lookupDomainWithClient(client, d, domainLimit(overrides, d), domainSubdomain(overrides, d))
+			}
+
+			if err == nil {
+				// Ordered results are recorded once they are processed
+				if ordered == nil {
+					resume.Record(d)
+				}
+			} else {
+				// Lookups cut short by the deadline end the run instead
+				stopOnDeadline()
+
+				// Don't report errors during shutdown
+				if !isShuttingDown() {
+					errCount.Add(1)
+					if isTimeout(err) {
+						timeoutCount.Add(1)
+					}
+					errLog.Record(d, err)
+					logError("Failed to process domain", "domain", d, "error", err)
+					if ordered == nil {
+						processFailure(d, err)
+					}
+				}
+			}
+
+			// Update progress counter
+			processedMutex.Lock()
+			processedCount++
+			done := processedCount
+			processedMutex.Unlock()
+
+			// Show progress periodically
+			if !*quietMode && !*noProgress && !isShuttingDown() {
+				// Slots still held (minus this one) are lookups in flight, retries included
+				inFlight := len(semaphore) - 1
+				queued := int64(totalDomains) - dispatchedCount.Load()
+				if progress != nil {
+					progress.Update(int(done), inFlight, queued)
+				} else if done%10 == 0 {
+					logInfo("Progress", "done", done, "total", totalDomains, "percent", fmt.Sprintf("%.1f", float64(done)/float64(totalDomains)*100),
+						"in_flight", inFlight, "queued", queued, symbol("⏱️"))
+				}
+			}
+		}(i, domain)
+	}
+
+	wg.Wait()
+	progress.Finish()
+	stopOnDeadline()
+
+	// Output final results
+	outputResults()
+
+	// The grand total of -count goes to stderr even with -q, so it never
+	// mixes with the per-domain counts on stdout
+	if *countOnly {
+		logSummary("Total unique hostnames", "count", countTotal, symbol("🔢"))
+	}
+
+	// Only a run in which every lookup failed is a failure
+	if errCount.Load() > 0 && errCount.Load() == dispatchedCount.Load() {
+		exitStatus = exitFailed
+	}
+
+	if !*quietMode && !isShuttingDown() {
+		elapsed := time.Since(initTime)
+		apexesMux.Lock()
+		logInfo("Found unique apex domains", "count", len(apexes), symbol("🌐"))
+		apexesMux.Unlock()
+
+		if errCount.Load() > 0 {
+			logWarn("Bulk lookup completed with errors", "errors", errCount.Load(), "timeouts", timeoutCount.Load(), "elapsed", elapsed.Round(time.Millisecond))
+		} else {
+			logInfo("Bulk lookup completed successfully", "elapsed", elapsed.Round(time.Millisecond), symbol("✅"))
+		}
+	}
+}
@@ -0,0 +1,428 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkgforge-security/crt/result"
+)
+
+// fakeAPI stands in for the crt.sh JSON API: each domain has one certificate
+// unless certs says otherwise, the domains in fail get a 502 and those in
+// timeout a timed out request
+type fakeAPI struct {
+	certs   map[string]int
+	fail    map[string]bool
+	timeout map[string]bool
+	delay   map[string]time.Duration // Before answering, unless the request is cancelled
+
+	mu      sync.Mutex
+	queries []string // Queried domains, in order
+}
+
+func (f *fakeAPI) RoundTrip(req *http.Request) (*http.Response, error) {
+	domain := req.URL.Query().Get("q")
+	f.mu.Lock()
+	f.queries = append(f.queries, domain)
+	f.mu.Unlock()
+
+	if d := f.delay[domain]; d > 0 {
+		select {
+		case <-time.After(d):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+
+	if f.timeout[domain] {
+		return nil, os.ErrDeadlineExceeded
+	}
+	if f.fail[domain] {
+		return &http.Response{StatusCode: http.StatusBadGateway, Status: "502 Bad Gateway", Body: io.NopCloser(strings.NewReader(""))}, nil
+	}
+
+	n, ok := f.certs[domain]
+	if !ok {
+		n = 1
+	}
+	records := make([]map[string]interface{}, n)
+	for i := range records {
+		records[i] = map[string]interface{}{
+			"issuer_ca_id":    1,
+			"issuer_name":     "C=US, O=Let's Encrypt, CN=R3",
+			"common_name":     domain,
+			"name_value":      fmt.Sprintf("%s\nwww%d.%s", domain, i, domain),
+			"id":              len(domain)*1000 + i,
+			"entry_timestamp": "2015-01-02T03:04:05.123",
+			"not_before":      "2015-01-02T00:00:00",
+			"not_after":       "2015-04-02T00:00:00",
+			"serial_number":   fmt.Sprintf("%x", i+1),
+		}
+	}
+	body, _ := json.Marshal(records)
+	return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Body: io.NopCloser(bytes.NewReader(body)), Request: req}, nil
+}
+
+// Queries returns the domains looked up so far
+func (f *fakeAPI) Queries() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.queries...)
+}
+
+// runBulk runs a bulk lookup of the domains of input against api, with args
+// as the flags (after -i, -o and flags that make it quick), and returns what
+// was logged and written to the -o file
+func runBulk(t *testing.T, api *fakeAPI, input string, args ...string) (logs, output string) {
+	t.Helper()
+	return runInput(t, performBulkLookup, api, input, args...)
+}
+
+// runInput is runBulk for any run reading its input from -i
+func runInput(t *testing.T, run func(), api http.RoundTripper, input string, args ...string) (logs, output string) {
+	t.Helper()
+	dir := t.TempDir()
+	inFile := filepath.Join(dir, "domains.txt")
+	outFile := filepath.Join(dir, "out")
+	if err := os.WriteFile(inFile, []byte(input), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	args = append([]string{"-i", inFile, "-o", outFile, "-backend", "http", "-d", "0", "-r", "0"}, args...)
+	if err := flag.CommandLine.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	defer resetRun()
+
+	var logBuf bytes.Buffer
+	defer func(h slog.Handler, l *slog.Logger) { logHandler, logger = h, l }(logHandler, logger)
+	logHandler = newHumanHandler(&logBuf, slog.LevelInfo)
+	logger = slog.New(logHandler)
+
+	defer func(rt http.RoundTripper) { http.DefaultTransport = rt }(http.DefaultTransport)
+	http.DefaultTransport = api
+
+	initTime = time.Now()
+	setupRenderOptions()
+	setupOutput()
+	var err error
+	if cache, err = openCache(); err != nil {
+		t.Fatal(err)
+	}
+	run()
+
+	data, err := os.ReadFile(outFile)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return logBuf.String(), string(data)
+}
+
+// resetRun puts the flags and the state of the run back as they were before
+// runBulk
+func resetRun() {
+	flag.VisitAll(func(f *flag.Flag) {
+		if !strings.HasPrefix(f.Name, "test.") && f.Value.String() != f.DefValue {
+			f.Value.Set(f.DefValue)
+		}
+	})
+
+	renderOpts = result.Options{}
+	apexes = make(map[string]bool)
+	shuttingDown = false
+	shutdownOnce, outputOnce = sync.Once{}, sync.Once{}
+	runCtx, cancelRun = context.WithCancel(context.Background())
+	foundResults.Store(false)
+	exitStatus = exitResults
+	errorBackoff.Store(0)
+
+	jsonResults, jsonlResults, htmlResults = nil, nil, nil
+	tableResults.Reset()
+	csvResults.Reset()
+	yamlResults.Reset()
+	mdResults.Reset()
+	plainResults.Reset()
+	countResults.Reset()
+	csvWriter, csvHeaderDone, countTotal = nil, false, 0
+	plainSeen = make(map[string]bool)
+	absFilename = ""
+
+	cache, resume, ordered, progress, rotator, stream, webhook, seenNames, merged = nil, nil, nil, nil, nil, nil, nil, nil, nil
+	diffPrevious, diffCurrent = nil, nil
+}
+
+func TestBulkNoProgress(t *testing.T) {
+	var input strings.Builder
+	for i := range 20 {
+		fmt.Fprintf(&input, "d%02d.com\n", i)
+	}
+	api := &fakeAPI{fail: map[string]bool{"d05.com": true}}
+
+	tests := []struct {
+		name     string
+		args     []string
+		progress bool
+	}{
+		{"progress", nil, true},
+		{"no progress", []string{"-no-progress"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs, _ := runBulk(t, api, input.String(), tt.args...)
+
+			for _, line := range []string{"Processing Domains", "Progress (done: 10, total: 20"} {
+				if got := strings.Contains(logs, line); got != tt.progress {
+					t.Errorf("%q logged = %v, want %v:\n%s", line, got, tt.progress, logs)
+				}
+			}
+			if !strings.Contains(logs, "❌ Failed to process domain (domain: d05.com") {
+				t.Errorf("error not logged:\n%s", logs)
+			}
+		})
+	}
+}
+
+func TestIsTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"context deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), true},
+		{"i/o deadline", os.ErrDeadlineExceeded, true},
+		{"net timeout", &net.DNSError{Err: "timeout", IsTimeout: true}, true},
+		{"statement timeout", &pq.Error{Code: "57014"}, true},
+		{"other database error", &pq.Error{Code: "42P01"}, false},
+		{"cancelled", context.Canceled, false},
+		{"other", errors.New("502 Bad Gateway"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTimeout(tt.err); got != tt.want {
+				t.Errorf("isTimeout(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBulkTimeoutSummary(t *testing.T) {
+	tests := []struct {
+		name    string
+		api     *fakeAPI
+		summary string
+	}{
+		{"no errors", &fakeAPI{}, "Bulk lookup completed successfully"},
+		{"errors", &fakeAPI{fail: map[string]bool{"b.com": true}}, "errors: 1, timeouts: 0"},
+		{"timeouts", &fakeAPI{fail: map[string]bool{"b.com": true}, timeout: map[string]bool{"c.com": true, "d.com": true}}, "errors: 3, timeouts: 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs, _ := runBulk(t, tt.api, "a.com\nb.com\nc.com\nd.com\n")
+			if !strings.Contains(logs, tt.summary) {
+				t.Errorf("summary %q not logged:\n%s", tt.summary, logs)
+			}
+		})
+	}
+}
+
+func TestErrorsLogStreams(t *testing.T) {
+	defer func(orig string) { *errorsFile = orig }(*errorsFile)
+	*errorsFile = filepath.Join(t.TempDir(), "errors.tsv")
+
+	errLog, err := openErrorsFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer errLog.Close()
+
+	tests := []struct {
+		domain string
+		err    error
+		want   string
+	}{
+		{"a.com", errors.New("502 Bad Gateway"), "a.com\t502 Bad Gateway\n"},
+		{"b.com", errors.New("line one\nline two"), "b.com\tline one line two\n"},
+	}
+
+	var want string
+	for _, tt := range tests {
+		errLog.Record(tt.domain, tt.err)
+		want += tt.want
+
+		// Every failure is on disk right away, nothing is held in memory
+		data, err := os.ReadFile(*errorsFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("errors file = %q, want %q", data, want)
+		}
+	}
+}
+
+func TestBulkManyErrors(t *testing.T) {
+	const domains = 2000
+	var input strings.Builder
+	api := &fakeAPI{fail: make(map[string]bool, domains)}
+	for i := range domains {
+		domain := fmt.Sprintf("d%d.com", i)
+		fmt.Fprintln(&input, domain)
+		api.fail[domain] = true
+	}
+
+	errorsPath := filepath.Join(t.TempDir(), "errors.tsv")
+	logs, _ := runBulk(t, api, input.String(), "-c", "50", "-errors-file", errorsPath)
+
+	data, err := os.ReadFile(errorsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != domains {
+		t.Errorf("errors file has %d lines, want %d", n, domains)
+	}
+	if want := fmt.Sprintf("errors: %d, timeouts: 0", domains); !strings.Contains(logs, want) {
+		t.Errorf("summary %q not logged", want)
+	}
+
+	// The heap a run grows by while its lookups fail mustn't scale with the
+	// input: ten times the domains may only add a little per extra domain
+	small, large := bulkErrorsHeapGrowth(t, domains), bulkErrorsHeapGrowth(t, 10*domains)
+	if perDomain := (large - small) / (9 * domains); perDomain > 64 {
+		t.Errorf("heap grew by %d bytes for %d failing domains and %d bytes for %d (%d bytes per extra domain)",
+			small, domains, large, 10*domains, perDomain)
+	}
+}
+
+// heapAPI fails every lookup, like fakeAPI with every domain in fail, and
+// measures the live heap when the 100th (once the run has warmed up) and the
+// last domain are looked up
+type heapAPI struct {
+	domains     int64
+	queries     atomic.Int64
+	first, last uint64
+}
+
+func (h *heapAPI) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch h.queries.Add(1) {
+	case 100:
+		h.first = liveHeap()
+	case h.domains:
+		h.last = liveHeap()
+	}
+	return &http.Response{StatusCode: http.StatusBadGateway, Status: "502 Bad Gateway", Body: io.NopCloser(strings.NewReader(""))}, nil
+}
+
+// liveHeap returns the bytes of the heap still reachable after a collection
+// (two, so the objects of sync.Pools are gone too)
+func liveHeap() uint64 {
+	runtime.GC()
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// bulkErrorsHeapGrowth runs a bulk lookup of n failing domains, without
+// logs (which the test would keep), and returns how much the live heap grew
+// from the 100th lookup to the last
+func bulkErrorsHeapGrowth(t *testing.T, n int) int64 {
+	t.Helper()
+	var input strings.Builder
+	for i := range n {
+		fmt.Fprintf(&input, "d%d.com\n", i)
+	}
+
+	api := &heapAPI{domains: int64(n)}
+	discard := func() {
+		logHandler = slog.NewTextHandler(io.Discard, nil)
+		logger = slog.New(logHandler)
+		performBulkLookup()
+	}
+	errorsPath := filepath.Join(t.TempDir(), "errors.tsv")
+	// One lookup at a time, so all others are done when the last one starts
+	runInput(t, discard, api, input.String(), "-c", "1", "-errors-file", errorsPath)
+	if api.last == 0 {
+		t.Fatalf("%d domains weren't all looked up (%d queries)", n, api.queries.Load())
+	}
+	return int64(api.last) - int64(api.first)
+}
+
+func TestBulkUniqueApexCount(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		args  []string
+		want  string
+	}{
+		{"distinct", "a.com\nb.com\n", nil, "Found unique apex domains (count: 2)"},
+		{"overlapping", "a.com\nwww.a.com\nb.co.uk\nx.b.co.uk\n", nil, "Found unique apex domains (count: 2)"},
+		{"subdomains", "a.com\nshop.a.com\nc.net\n", []string{"-s"}, "Found unique apex domains (count: 2)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs, _ := runBulk(t, &fakeAPI{}, tt.input, tt.args...)
+			if !strings.Contains(logs, tt.want) {
+				t.Errorf("%q not logged:\n%s", tt.want, logs)
+			}
+		})
+	}
+}
+
+func TestBulkProgressCounts(t *testing.T) {
+	var input strings.Builder
+	for i := range 20 {
+		fmt.Fprintf(&input, "d%02d.com\n", i)
+	}
+
+	tests := []struct {
+		name string
+		c    string
+		slow []string // Still in flight when the others are done
+		want []string
+	}{
+		{"one at a time", "1", nil, []string{
+			"Progress (done: 10, total: 20, percent: 50.0, in_flight: 0, queued: 10)",
+			"Progress (done: 20, total: 20, percent: 100.0, in_flight: 0, queued: 0)",
+		}},
+		{"slow lookups hold their slots", "3", []string{"d00.com", "d01.com"}, []string{
+			"Progress (done: 10, total: 20, percent: 50.0, in_flight: 2, queued: 8)",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeAPI{delay: make(map[string]time.Duration)}
+			for _, domain := range tt.slow {
+				api.delay[domain] = 300 * time.Millisecond
+			}
+
+			logs, _ := runBulk(t, api, input.String(), "-c", tt.c)
+			for _, line := range tt.want {
+				if !strings.Contains(logs, line) {
+					t.Errorf("%q not logged:\n%s", line, logs)
+				}
+			}
+		})
+	}
+}
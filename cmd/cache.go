@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkgforge-security/crt/crt"
+	"github.com/pkgforge-security/crt/result"
+	_ "modernc.org/sqlite"
+)
+
+// cacheSchema creates the table of the -cache database, holding the JSON
+// results of each query by key
+const cacheSchema = `CREATE TABLE IF NOT EXISTS results (
+	key        TEXT PRIMARY KEY,
+	fetched_at INTEGER NOT NULL,
+	data       TEXT NOT NULL
+)`
+
+// resultCache keeps query results in a local SQLite database (-cache), so
+// that repeated runs within -cache-ttl don't query crt.sh again
+type resultCache struct {
+	db  *sql.DB
+	ttl time.Duration
+}
+
+// cache is shared by every lookup of the run
+var cache *resultCache
+
+// openCache opens (and creates) the -cache database; without it, Get always
+// misses and Put is a no-op
+func openCache() (*resultCache, error) {
+	c := &resultCache{ttl: *cacheTTL}
+	if *cacheFile == "" {
+		return c, nil
+	}
+
+	// Another run writing the database makes this one wait for it, up to the
+	// busy timeout, instead of failing at once
+	dsn := (&url.URL{Scheme: "file", Opaque: *cacheFile, RawQuery: "_pragma=busy_timeout(5000)"}).String()
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite has a single writer; the lookups of a bulk run share one
+	// connection rather than failing with SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(cacheSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	c.db = db
+	return c, nil
+}
+
+// cacheKey identifies a query by its kind, domain, the database or API it
+// goes to and every flag that changes what it returns; -since, -until and
+// -expiring are keyed by the times they resolved to
+func cacheKey(kind, domain string, limit int) string {
+	return fmt.Sprintf("%s|%s|backend=%s|hosts=%s|port=%d|db=%s|expired=%t|limit=%d|since=%s|until=%s|cert-id=%d-%d|expiring=%s",
+		kind, domain, *backend, strings.Join(dbHostList(), ","), *dbPort, envDefault(*dbName, "CRT_DB_NAME"),
+		*expired, limit, cacheTime(sinceTime, relativeSince(*since)), cacheTime(untilTime, relativeSince(*until)),
+		*minCertID, *maxCertID, cacheTime(expiringBy, true))
+}
+
+// cacheTime formats a time bound of a cache key, which is empty when unset.
+// Times resolved from a relative value (e.g. -since 7d) move with every run,
+// so they are rounded down to the TTL for the runs in between to share entries
+func cacheTime(t time.Time, relative bool) string {
+	if t.IsZero() {
+		return ""
+	}
+	if relative {
+		t = t.Truncate(*cacheTTL)
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// relativeSince reports whether a -since or -until value is a duration before
+// now rather than a date or time (see parseSince)
+func relativeSince(value string) bool {
+	_, dateErr := time.Parse("2006-01-02", value)
+	_, timeErr := time.Parse(time.RFC3339, value)
+	return value != "" && dateErr != nil && timeErr != nil
+}
+
+// Get decodes the entry of key into v, reporting whether it was found and
+// is younger than the TTL
+func (c *resultCache) Get(key string, v interface{}) bool {
+	if c.db == nil {
+		return false
+	}
+
+	var (
+		fetchedAt int64
+		data      string
+	)
+	err := c.db.QueryRow("SELECT fetched_at, data FROM results WHERE key = ?", key).Scan(&fetchedAt, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false
+	}
+	if err != nil {
+		logWarn("Failed to read cache", "error", err)
+		return false
+	}
+	if time.Since(time.Unix(fetchedAt, 0)) > c.ttl {
+		return false
+	}
+	return json.Unmarshal([]byte(data), v) == nil
+}
+
+// Put stores v as the entry of key, replacing any older one
+func (c *resultCache) Put(key string, v interface{}) {
+	if c.db == nil {
+		return
+	}
+
+	data, err := json.Marshal(v)
+	if err == nil {
+		_, err = c.db.Exec("INSERT OR REPLACE INTO results (key, fetched_at, data) VALUES (?, ?, ?)",
+			key, time.Now().Unix(), string(data))
+	}
+	if err != nil {
+		logWarn("Failed to write cache", "error", err)
+	}
+}
+
+func (c *resultCache) Close() error {
+	if c.db == nil {
+		return nil
+	}
+	return c.db.Close()
+}
+
+// cachedCertificates serves the certificates of key from the cache, or else
+// fetches and caches them
+func cachedCertificates(key string, fetch func() (result.Certificates, error)) (result.Certificates, error) {
+	var certs result.Certificates
+	if cache.Get(key, &certs) {
+		return certs, nil
+	}
+
+	certs, err := fetch()
+	if err == nil {
+		cache.Put(key, certs)
+	}
+	return certs, err
+}
+
+// getCertLogs is client.Lookup, served from the cache when possible
+func getCertLogs(client *crt.Client, domain string, limit int) (result.Certificates, error) {
+	return cachedCertificates(cacheKey("certs", domain, limit), func() (result.Certificates, error) {
+		return client.Lookup(runCtx, domain, queryOptions(limit))
+	})
+}
+
+// getSubdomains is client.Subdomains, served from the cache when possible
+func getSubdomains(client *crt.Client, domain string, limit int) (result.Subdomains, error) {
+	key := cacheKey("subdomains", domain, limit)
+	var subs result.Subdomains
+	if cache.Get(key, &subs) {
+		return subs, nil
+	}
+
+	subs, err := client.Subdomains(runCtx, domain, queryOptions(limit))
+	if err == nil {
+		cache.Put(key, subs)
+	}
+	return subs, err
+}
+
+// getCategorizedCertLogs is client.Categorized, served from the cache when
+// possible
+func getCategorizedCertLogs(client *crt.Client, domain string, limit int) (apex, subs result.Certificates, err error) {
+	key := cacheKey("categorized", domain, limit)
+	var entry struct{ Apex, Subs result.Certificates }
+	if cache.Get(key, &entry) {
+		return entry.Apex, entry.Subs, nil
+	}
+
+	apex, subs, err = client.Categorized(runCtx, domain, queryOptions(limit))
+	if err == nil {
+		cache.Put(key, struct{ Apex, Subs result.Certificates }{apex, subs})
+	}
+	return apex, subs, err
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkgforge-security/crt/result"
+)
+
+func TestResultCache(t *testing.T) {
+	defer func(file string, ttl time.Duration) { *cacheFile, *cacheTTL = file, ttl }(*cacheFile, *cacheTTL)
+	*cacheFile = filepath.Join(t.TempDir(), "cache.db")
+	*cacheTTL = time.Hour
+
+	c, err := openCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	want := result.Subdomains{{Name: "www.example.com"}, {Name: "api.example.com"}}
+	c.Put("subdomains|example.com", want)
+
+	tests := []struct {
+		name  string
+		key   string
+		found bool
+	}{
+		{"stored", "subdomains|example.com", true},
+		{"other key", "subdomains|example.org", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got result.Subdomains
+			if found := c.Get(tt.key, &got); found != tt.found {
+				t.Fatalf("Get(%q) found = %t, want %t", tt.key, found, tt.found)
+			}
+			if tt.found && !reflect.DeepEqual(got, want) {
+				t.Errorf("Get(%q) = %v, want %v", tt.key, got, want)
+			}
+		})
+	}
+
+	// Entries older than the TTL are misses
+	c.ttl = -time.Second
+	var got result.Subdomains
+	if c.Get("subdomains|example.com", &got) {
+		t.Error("Get found an expired entry")
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	defer func(b string, s, u time.Time) { *backend, sinceTime, untilTime = b, s, u }(*backend, sinceTime, untilTime)
+
+	base := cacheKey("certs", "example.com", 10)
+	tests := []struct {
+		name  string
+		setup func()
+	}{
+		{"backend", func() { *backend = "http" }},
+		{"since", func() { sinceTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) }},
+		{"until", func() { untilTime = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			if key := cacheKey("certs", "example.com", 10); key == base {
+				t.Errorf("key %q didn't change with %s", key, tt.name)
+			}
+		})
+	}
+}
+
+func TestCacheKeyRelativeTimes(t *testing.T) {
+	defer func(s string, ttl time.Duration, st time.Time) {
+		*since, *cacheTTL, sinceTime = s, ttl, st
+	}(*since, *cacheTTL, sinceTime)
+	*cacheTTL = time.Hour
+
+	// Runs a minute apart, within the same hour
+	first := time.Date(2024, 5, 1, 10, 20, 0, 0, time.UTC)
+	next := first.Add(time.Minute)
+
+	tests := []struct {
+		name  string
+		since string
+	}{
+		{"days", "7d"},
+		{"duration", "12h"},
+		{"date", "2024-04-01"},
+		{"rfc3339", "2024-04-01T10:20:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*since = tt.since
+			key := func(now time.Time) string {
+				var err error
+				if sinceTime, err = parseSince(tt.since, now, false); err != nil {
+					t.Fatal(err)
+				}
+				return cacheKey("certs", "example.com", 10)
+			}
+			if a, b := key(first), key(next); a != b {
+				t.Errorf("runs a minute apart have the keys %q and %q", a, b)
+			}
+		})
+	}
+
+	// An absolute time keeps its precision
+	*since = "2024-04-01T10:20:00Z"
+	sinceTime = time.Date(2024, 4, 1, 10, 20, 0, 0, time.UTC)
+	a := cacheKey("certs", "example.com", 10)
+	sinceTime = sinceTime.Add(time.Minute)
+	if b := cacheKey("certs", "example.com", 10); a == b {
+		t.Errorf("absolute -since times a minute apart share the key %q", a)
+	}
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// checkpoint records the domains of a bulk run that completed (-resume), so
+// a restarted run can skip them
+type checkpoint struct {
+	mu   sync.Mutex
+	file *os.File
+	done map[string]bool
+}
+
+// resume is the checkpoint of the bulk run
+var resume *checkpoint
+
+// openCheckpoint loads the domains already recorded in -resume and opens it
+// for appending; without it, Record is a no-op and nothing is skipped
+func openCheckpoint() (*checkpoint, error) {
+	c := &checkpoint{done: make(map[string]bool)}
+	if *resumeFile == "" {
+		return c, nil
+	}
+
+	if file, err := os.Open(*resumeFile); err == nil {
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if domain := strings.TrimSpace(scanner.Text()); domain != "" {
+				c.done[domainKey(domain)] = true
+			}
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(*resumeFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	c.file = file
+	return c, nil
+}
+
+// Pending returns the domains not recorded yet, in order; like the input
+// deduplication, case and a trailing dot don't matter
+func (c *checkpoint) Pending(domains []string) []string {
+	if len(c.done) == 0 {
+		return domains
+	}
+
+	pending := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		if !c.done[domainKey(domain)] {
+			pending = append(pending, domain)
+		}
+	}
+	return pending
+}
+
+// Record marks domain as completed, once its results are written to the
+// output. Each line is written straight to the file, so it survives the
+// process being killed right after.
+func (c *checkpoint) Record(domain string) {
+	if c == nil || c.file == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := fmt.Fprintln(c.file, domain); err != nil {
+		logError("Failed to write checkpoint", "error", err)
+	}
+}
+
+func (c *checkpoint) Close() error {
+	if c.file == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.file.Close()
+}
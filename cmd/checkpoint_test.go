@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCheckpointPending(t *testing.T) {
+	tests := []struct {
+		name     string
+		recorded string
+		domains  []string
+		want     []string
+	}{
+		{"nothing recorded", "", []string{"a.com", "b.com"}, []string{"a.com", "b.com"}},
+		{"exact match", "a.com\n", []string{"a.com", "b.com"}, []string{"b.com"}},
+		{"case differs", "Example.COM\n", []string{"example.com", "b.com"}, []string{"b.com"}},
+		{"trailing dot", "example.com.\n", []string{"EXAMPLE.com", "b.com"}, []string{"b.com"}},
+		{"blank lines", "\n  a.com  \n\n", []string{"a.com"}, []string{}},
+	}
+
+	defer func(orig string) { *resumeFile = orig }(*resumeFile)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*resumeFile = filepath.Join(t.TempDir(), "resume.txt")
+			if err := os.WriteFile(*resumeFile, []byte(tt.recorded), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := openCheckpoint()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			if got := c.Pending(tt.domains); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Pending(%v) = %v, want %v", tt.domains, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckpointRecord(t *testing.T) {
+	defer func(orig string) { *resumeFile = orig }(*resumeFile)
+	*resumeFile = filepath.Join(t.TempDir(), "resume.txt")
+
+	c, err := openCheckpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Record("a.com")
+	c.Record("B.com")
+	c.Close()
+
+	if c, err = openCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if got := c.Pending([]string{"a.com", "b.com", "c.com"}); !reflect.DeepEqual(got, []string{"c.com"}) {
+		t.Errorf("Pending after Record = %v, want [c.com]", got)
+	}
+
+	// Without -resume, nothing is recorded or skipped
+	var none *checkpoint
+	none.Record("a.com")
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/atotto/clipboard"
+)
+
+// ansiEscape matches the color codes tables are rendered with
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// errNoClipboard is returned on systems without clipboard support (e.g. headless)
+var errNoClipboard = errors.New("no clipboard available on this system")
+
+// clipboardWrite puts text on the system clipboard (replaced in tests)
+var clipboardWrite = func(text string) error {
+	if clipboard.Unsupported {
+		return errNoClipboard
+	}
+	return clipboard.WriteAll(text)
+}
+
+// copyToClipboard copies data, stripped of colors, to the system clipboard
+func copyToClipboard(data []byte) error {
+	return clipboardWrite(string(ansiEscape.ReplaceAll(data, nil)))
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import "testing"
+
+func TestCopyToClipboard(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "example.com\n", "example.com\n"},
+		{"colored", "\x1b[1;32mexample.com\x1b[0m\n", "example.com\n"},
+		{"reset only", "a\x1b[mb", "ab"},
+		{"empty", "", ""},
+	}
+
+	orig := clipboardWrite
+	defer func() { clipboardWrite = orig }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			clipboardWrite = func(text string) error {
+				got = text
+				return nil
+			}
+
+			if err := copyToClipboard([]byte(tt.in)); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("copied %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
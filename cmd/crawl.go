@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pkgforge-security/crt/crt"
+	"github.com/pkgforge-security/crt/result"
+)
+
+// crawlCertLogs enumerates the subdomains of domain and looks up the
+// certificates of each (and of domain itself), with up to -c queries in
+// flight, each waiting for -d and returning at most limit certificates. A
+// wildcard name is looked up as its base name. Failed names are skipped, so
+// an error is only returned if every lookup failed. It also reports whether
+// the certificates are complete, i.e. no lookup reached the limit.
+func crawlCertLogs(client *crt.Client, domain string, limit int) (result.Certificates, bool, error) {
+	subs, err := getSubdomains(client, domain, limit)
+	if err != nil {
+		return nil, false, err
+	}
+	complete := len(subs) < limit
+
+	seen := make(map[string]bool, len(subs)+1)
+	var names []string
+	for _, name := range append([]string{domain}, subNames(subs)...) {
+		name = strings.TrimSuffix(strings.ToLower(strings.TrimPrefix(name, "*.")), ".")
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	logInfo("Crawling the Certificates of the Names under the Domain", "domain", domain, "names", len(names))
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		certs     result.Certificates
+		failed    int
+		lastErr   error
+		semaphore = make(chan struct{}, max(*concurrent, 1))
+	)
+	for _, name := range names {
+		if isShuttingDown() {
+			break
+		}
+
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(name string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			waitTurn()
+			res, err := getCertLogs(client, name, limit)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				recordFailure()
+				logWarn("Failed to look up name", "name", name, "error", err)
+				failed++
+				lastErr = err
+				return
+			}
+			recordSuccess()
+			if len(res) >= limit {
+				complete = false
+			}
+			certs = append(certs, res...)
+		}(name)
+	}
+	wg.Wait()
+
+	if failed > 0 && failed == len(names) {
+		return nil, false, fmt.Errorf("every lookup failed: %w", lastErr)
+	}
+	return certs.DedupeIDs(), complete && failed == 0, nil
+}
+
+// subNames returns the names of subs
+func subNames(subs result.Subdomains) []string {
+	names := make([]string, len(subs))
+	for i, sub := range subs {
+		names[i] = sub.Name
+	}
+	return names
+}
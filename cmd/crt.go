@@ -1,19 +1,20 @@
 package cmd
 
 import (
-	"bufio"
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
 	"os"
 	"os/signal"
-	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
+
+	"github.com/pkgforge-security/crt/crt"
 	"github.com/pkgforge-security/crt/repository"
 	"github.com/pkgforge-security/crt/result"
 )
@@ -21,17 +22,114 @@ import (
 var (
 	initTime time.Time
 	concurrent   = flag.Int("c", 5, "")
+	countOnly    = flag.Bool("count", false, "")
 	csvOut       = flag.Bool("csv", false, "")
+	tsvOut       = flag.Bool("tsv", false, "")
+	backend      = flag.String("backend", "auto", "")
+	cacheFile    = flag.String("cache", "", "")
+	cacheTTL     = flag.Duration("cache-ttl", 24*time.Hour, "")
+	dbHosts      = listFlag("db-host")
+	dbPort       = flag.Int("db-port", 0, "")
+	dbUser       = flag.String("db-user", "", "")
+	dbName       = flag.String("db-name", "", "")
+	dbSSLMode    = flag.String("db-sslmode", "", "")
+	dbRootCert   = flag.String("db-sslrootcert", "", "")
+	dbSSLCert    = flag.String("db-sslcert", "", "")
+	dbSSLKey     = flag.String("db-sslkey", "", "")
+	csvBOM       = flag.Bool("csv-bom", false, "")
+	csvNoHeader  = flag.Bool("csv-no-header", false, "")
+	clip         = flag.Bool("clip", false, "")
+	compact      = flag.Bool("compact", false, "")
+	tree         = flag.Bool("tree", false, "")
+	categorize   = flag.Bool("categorize", false, "")
+	dedupe       = flag.Bool("dedupe", false, "")
+	delayOnError = flag.Int("delay-on-error", 0, "")
+	diffFile     = flag.String("diff", "", "")
 	expired      = flag.Bool("e", false, "")
+	expandSANs   = flag.Bool("expand", false, "")
+	emitEmpty    = flag.Bool("emit-empty", false, "")
+	errorsFile   = flag.String("errors-file", "", "")
+	expiryGroups = flag.Bool("expiry-groups", false, "")
+	fields       = flag.String("fields", "", "")
 	filename     = flag.String("o", "", "")
+	flushEvery   = flag.Duration("flush-interval", 0, "")
+	forceColor   = flag.Bool("force-color", false, "")
+	noColor      = flag.Bool("no-color", false, "")
+	noDedupe     = flag.Bool("no-dedupe-input", false, "")
+	uniqAcross   = flag.Bool("uniq-across-domains", false, "")
+	nrdDays      = flag.Int("nrd-days", result.DefaultNRDDays, "")
+	minResults   = flag.Int("min-results", result.DefaultNRDMinResults, "")
 	inputFile    = flag.String("i", "", "")
 	jsonOut      = flag.Bool("json", false, "")
+	jsonEnvelope = flag.Bool("json-envelope", false, "")
+	jsonStatus   = flag.Bool("json-status", false, "")
+	jsonAppend   = flag.Bool("json-append", false, "")
+	streamJSON   = flag.Bool("json-stream", false, "")
+	jsonStrIDs   = flag.Bool("json-string-ids", false, "")
+	jsonLD       = flag.Bool("jsonld", false, "")
+	appendOut    = flag.Bool("append", false, "")
+	forceOut     = flag.Bool("force", false, "")
+	jsonDedupe   = flag.Bool("json-append-dedupe", false, "")
 	jsonlOut     = flag.Bool("jsonl", false, "")
+	yamlOut      = flag.Bool("yaml", false, "")
+	mdOut        = flag.Bool("md", false, "")
+	htmlOut      = flag.Bool("html", false, "")
+	hostsFile    = flag.Bool("hosts-file", false, "")
 	limit        = flag.Int("l", 10, "")
+	matchRegex   = flag.String("match", "", "")
+	excludeRegex = flag.String("exclude", "", "")
+	maxRuntime   = flag.Duration("max-runtime", 0, "")
+	timeout      = flag.Duration("timeout", 0, "")
+	maxIdle      = flag.Duration("max-idle-before-reconnect", 0, "")
+	minCertID    = flag.Int64("min-cert-id", 0, "")
+	maxCertID    = flag.Int64("max-cert-id", 0, "")
+	noFooter     = flag.Bool("no-footer", false, "")
+	noProgress   = flag.Bool("no-progress", false, "")
+	orderedOut   = flag.Bool("ordered", false, "")
+	mergeOut     = flag.Bool("merge", false, "")
+	ownCert      = flag.Bool("own-cert", false, "")
+	plainOut     = flag.Bool("plain", false, "")
+	apexOut      = flag.Bool("apex", false, "")
 	quietMode    = flag.Bool("q", false, "")
+	logLevel     = flag.String("log-level", "info", "")
+	logJSON      = flag.Bool("log-json", false, "")
+	queriedAt    = flag.Bool("queried-at", false, "")
+	queryComment = flag.String("query-comment", "", "")
+	relativeTime = flag.Bool("relative-time", false, "")
+	verbose      = flag.Bool("verbose", false, "")
+	showVersion  = flag.Bool("version", false, "")
+	showSchema   = flag.Bool("schema", false, "")
 	requestDelay = flag.Int("d", 500, "")
+	resolveOnly  = flag.Bool("resolve-only", false, "")
+	resolve      = flag.Bool("resolve", false, "")
+	resolvedOnly = flag.Bool("resolved-only", false, "")
+	sanSummary   = flag.Int("san-summary", 0, "")
+	seed         = flag.Int64("seed", 0, "")
+	serialNo     = flag.String("serial", "", "")
+	issuerQuery  = flag.String("issuer", "", "")
+	orgQuery     = flag.String("org", "", "")
+	serialFormat = flag.String("serial-format", "", "")
+	shard        = flag.String("shard", "", "")
+	since        = flag.String("since", "", "")
+	until        = flag.String("until", "", "")
+	expiringDays = flag.Int("expiring", 0, "")
+	sortBy       = flag.String("sort", "", "")
 	retryCount   = flag.Int("r", 3, "")
+	resumeFile   = flag.String("resume", "", "")
+	reverse      = flag.Bool("reverse", false, "")
+	retryJitter  = flag.Float64("retry-jitter", 0.5, "")
+	retryMaxWait = flag.Duration("retry-max-delay", 30*time.Second, "")
+	retryOnEmpty = flag.Bool("retry-on-empty", false, "")
+	rotate       = flag.Int("rotate", 0, "")
 	subdomain    = flag.Bool("s", false, "")
+	crawlAll     = flag.Bool("all", false, "")
+	wildcard     = flag.Bool("wildcard", false, "")
+	wildcardOnly = flag.Bool("wildcards-only", false, "")
+	noWildcards  = flag.Bool("no-wildcards", false, "")
+	webhookURL   = flag.String("webhook", "", "")
+	webhookBatch = flag.Int("webhook-batch", 100, "")
+	webhookGzip  = flag.Bool("webhook-gzip", false, "")
+	suspicious   = flag.Bool("suspicious", false, "")
 )
 
 var usage = `Usage: crt [options...] <domain name>
@@ -39,23 +137,136 @@ var usage = `Usage: crt [options...] <domain name>
 NOTE: 
   → Options must come before Input (Unless using -i)
   → Each connection is opened only for 5 Mins, with 3 Retries
-  → NRD Indicator needs at least 3 Results to be Accurate
+  → NRD Indicator needs fewer Results than the Limit of the Domain (-l, or its input line),
+    so the oldest Certificate is included, and at least -min-results of them
   → To pipe to other Tools, use -q 2>/dev/null | ${TOOL}
   → For Bulk mode, Always use -o to prevent Data Loss
 
 Options:
+  -backend <name>  Query the database (db), the crt.sh JSON API (http) or the database with
+            the API as fallback (auto) [Default: auto]
+  -db-host <host[:port]>  Database host to query; repeat to race every query across mirrors [Default: crt.sh] [Env: CRT_DB_HOST]
+  -db-port <int>  Database port [Default: 5432] [Env: CRT_DB_PORT]
+  -db-user <name>  Database user [Default: guest] [Env: CRT_DB_USER]
+  -db-name <name>  Database name [Default: certwatch] [Env: CRT_DB_NAME]
+  -db-sslmode <mode>  Database sslmode: disable, require, verify-ca or verify-full [Default: libpq default]
+  -db-sslrootcert <path>  CA certificate to verify the database server with
+  -db-sslcert <path>  Client certificate for the database connection
+  -db-sslkey <path>  Client key for the database connection
+  -query-comment <str>  Tag queries with a /* comment */ for server-side logging
   -e        Exclude Expired Certificates [Default: False]
   -s        Enumerate Subdomains [Default: False]
-  -c <int>  Number of concurrent lookups for Bulk Mode [Default: 5]
-  -d <int>  Delay between requests in milliseconds [Default: 500]
-  -i <path> Input file containing domain names (one per line) for bulk lookup
+  -all      Enumerate the subdomains, then merge the certificates of each into one result (-l applies per name)
+  -serial <hex>  Look up the certificates with this serial number (e.g. 03:A1:FF or 03a1ff) instead of a domain
+  -issuer <name>  Look up the certificates whose issuer contains this name (e.g. "Let's Encrypt") instead of a domain
+  -org <name>  Look up the certificates issued to this organization (subject O=) instead of a domain
+  -diff <path>  Only output certificates added (+) or removed (-) since a previous -json/-jsonl result file
+  -dedupe   Collapse certificates with the same name_value into the most recently logged one
+  -expand   Output one row per SAN in name_value, deduplicated like -dedupe
+  -nrd-days <int>  Flag domains whose oldest certificate is younger than this as NRD [Default: 90]
+  -min-results <int>  Only flag domains with at least this many certificates as NRD, warning about
+            the others [Default: 3]
+  -categorize  Split certificates into apex and subdomain certificates
+  -expiry-groups  Summarize certificates as expired, <30d, <90d and valid counts
+  -tree     Render subdomains as a tree grouped by label [Requires -s]
+  -own-cert  Mark subdomains that have their own certificate (extra query) [Requires -s]
+  -suspicious  Flag subdomains with mixed-script or look-alike characters [Requires -s]
+  -wildcard  Show whether subdomains are wildcard names (*.example.com) [Requires -s]
+  -wildcards-only  Only keep wildcard subdomains [Requires -s]
+  -no-wildcards  Drop wildcard subdomains [Requires -s]
+  -c <int>  Number of concurrent lookups for Bulk Mode and -all, and of DNS lookups per domain [Default: 5]
+  -d <int>  Minimum delay between requests in milliseconds, shared by all concurrent lookups (0 = no limit) [Default: 500]
+  -delay-on-error <int>  Extra delay in milliseconds added after each failure, decaying on success [Default: 0]
+  -i <path> Input file containing domain names (one per line) for bulk lookup [Default: STDIN, if piped]
+            A line may override -l for its domain: "example.com 50", or be a JSON object
+            overriding -l and -s: {"domain":"example.com","limit":20,"subdomain":true}
   -l <int>  Limit the number of results (more results take more time) [Default: 10]
-  -o <path> Output file path [Default: STDOUT]
+  -cache <path>  Keep query results in this SQLite database file and reuse them instead of querying crt.sh
+  -cache-ttl <duration>  Maximum age of the cached results reused with -cache [Default: 24h]
+  -timeout <duration>  Stop and save partial results after this long (e.g. 30m), also cancelling
+            running queries [Default: Unlimited] (-max-runtime is an alias)
+  -max-idle-before-reconnect <duration>  Reconnect instead of reusing a connection idle for longer than this (e.g. 5m) [Default: Disabled]
+  -since <date|age>  Only include certificates logged since a date (YYYY-MM-DD) or age (e.g. 7d, 12h)
+  -until <date|age>  Only include certificates logged until a date (inclusive) or age
+  -expiring <days>  Only include unexpired certificates expiring within this many days, with a
+            "Days Left" column in the table [Default: 0 (Disabled)]
+  -min-cert-id <int>  Only include certificates with crt.sh ID >= this (inclusive)
+  -max-cert-id <int>  Only include certificates with crt.sh ID <= this (inclusive)
+  -o <path> Output file path [Default: STDOUT]; "{domain}" in it writes each domain to its own file
+            (e.g. results/{domain}.json), creating directories as needed
+  -flush-interval <duration>  Also fsync the -o file at this interval (e.g. 10s) [Default: Disabled]
+  -rotate <int>  Write at most this many records per file (out.1.jsonl, out.2.jsonl, ...) [Requires -jsonl and -o]
+  -append   Add to the contents of the -o file instead of replacing them
+            (JSON is merged into the array in the file, like -json-append)
+  -force    Replace the contents of a non-empty -o file (without it or -append, such a file is an error)
   -r <int>  Number of retries for failed requests [Default: 3]
+  -retry-max-delay <duration>  Cap of the delay between retries, which doubles on every attempt [Default: 30s]
+  -retry-jitter <float>  Random extra delay between retries, as a fraction of the delay [Default: 0.5]
+  -retry-on-empty  Also retry (up to -r) when a query returns no results
+  -seed <int>  Seed for all randomized behavior, for reproducible runs [Default: Time-based]
   -csv      Turn results to CSV
+  -csv-bom  Prepend a UTF-8 BOM to CSV output (for Excel) [Requires -csv]
+  -csv-no-header  Omit the CSV header row entirely [Requires -csv or -tsv]
+  -tsv      Turn results to TSV (the columns of CSV, tab-separated and unquoted)
+  -resolve  Resolve the subdomains (A/AAAA, up to -c lookups at once) and show their IPs [Requires -s]
+  -resolved-only  Drop the subdomains that don't resolve [Requires -resolve, -hosts-file or -resolve-only]
+  -resolve-only  Only resolve the hostnames from -i (or STDIN), without querying crt.sh
+  -hosts-file  Resolve subdomains and print them as /etc/hosts lines (IP hostname) [Requires -s or -resolve-only]
+  -match <regex>  Only keep subdomains (or certificates with a name_value) matching this
+  -exclude <regex>  Drop subdomains (or names of certificates) matching this; wins over -match
+  -sort <field>  Sort results by a certificate field (see -fields) before output, in every format; "name" with -s
+            [Default: Newest entry_timestamp first, subdomains by name]
+  -reverse  Reverse the sort order
+  -fields <list>  Comma-separated certificate fields to output, in order (CSV/TSV/JSON/YAML), including
+            the virtual fields validity_days, san_count, crtsh_url and apex
   -json     Turn results to JSON
+  -json-append  Merge results into the existing JSON array in the -o file [Requires -json]
+  -json-append-dedupe  Skip results whose id is already in the -o file [Requires -json-append]
+  -json-envelope  Wrap JSON results as {"query":{...},"results":[...]} [Requires -json]
+  -json-status  Group JSON results per domain as {"domain":...,"error":...,"results":[...]}, so failed and
+            empty domains are listed too [Requires -json or -jsonl]
+  -json-stream  Write the JSON array to the -o file as results come in, instead of at the end [Requires -json]
+  -serial-format <fmt>  Normalize serial numbers as hex (03a1ff) or colon (03:a1:ff) [Default: As returned]
+  -json-string-ids  Serialize id and issuer_ca_id as strings (for JavaScript consumers)
   -jsonl    Turn results to JSONL (JSON Lines)
-  -q        Quiet mode (Hide progress messages, only show results) [Bulk Mode Only]
+  -jsonld   Turn certificate results to JSON-LD (@context/@graph) for web publishing
+  -yaml     Turn results to YAML (same fields as JSON)
+  -md       Turn results to GitHub-flavored Markdown tables (same columns as the table)
+  -html     Turn results to a self-contained HTML report with sortable tables (a section per domain)
+  -plain    Print only the unique hostnames (SANs or subdomains), one per line
+  -apex     Print only the unique registrable domains (eTLD+1, e.g. example.co.uk) of the hostnames, one per line
+  -count    Print only "domain<TAB>N", the number of unique hostnames found per domain
+  -san-summary <int>  Show certificates with more SANs than this as a count, listing the SANs
+            below the table (or as a "sans" array in JSON) [Default: 0 (Disabled)]
+  -relative-time  Add "3d ago" / "in 45d" columns for logged and expiry dates to the table
+  -verbose  Add the certificate ID and its crt.sh URL (https://crt.sh/?id=<ID>) columns to the table
+  -queried-at  Stamp each record with the time it was queried (queried_at, RFC3339)
+  -emit-empty  Write an empty table, CSV header or JSON array for domains without results
+  -no-color  Disable table colors [Default: Colors only when writing to a terminal]
+  -force-color  Keep table colors even when writing to a file or pipe (e.g. less -R)
+  -compact  Render tables without borders, row lines, padding or footer
+  -no-footer  Omit the table footer that repeats the header
+  -errors-file <path>  Write failed domains and their errors to this file [Bulk Mode Only]
+  -resume <path>  Record completed domains in this file and skip those already in it, to
+            continue an interrupted run (needs -o with -append or {domain}) [Bulk Mode Only]
+  -no-dedupe-input  Query duplicate domains in the input file again [Bulk Mode Only]
+  -uniq-across-domains  Output each subdomain only once, under the first domain to report it (in input order with -ordered) [Bulk Mode Only]
+  -ordered  Keep results in input file order [Bulk Mode Only]
+  -merge    Render the results of all domains as one table (or CSV/TSV/Markdown/HTML), with a Domain column [Bulk Mode Only]
+  -shard <i/n>  Only process the i-th of n partitions of the input file (e.g. 1/4) [Bulk Mode Only]
+  -webhook <url>  POST results as JSON arrays to this endpoint
+  -webhook-batch <int>  Number of records per webhook request [Default: 100]
+  -webhook-gzip  Gzip webhook request bodies
+  -clip     Also copy the results to the system clipboard [STDOUT Only]
+  -q        Quiet mode (Hide progress messages, only show results and errors, like -log-level error)
+  -log-level <level>  Only print messages of this level or above: debug (adds query timings), info,
+            warn or error [Default: info]
+  -log-json  Print messages as JSON lines ({"time":...,"level":...,"msg":...}) for log collectors
+  -no-progress  Hide only the processing line and progress bar (a line per 10 domains when not on a
+            terminal), keep errors and summary [Bulk Mode Only]
+  -version  Print the version, git commit and build date, then exit
+  -schema   Print the JSON Schema of the certificate and subdomain objects of -json (honoring
+            -json-string-ids), then exit
 
 Examples:
   crt "example.com"
@@ -65,109 +276,246 @@ Examples:
   crt -jsonl -q -s "example.com" 2>/dev/null | jq -r ".subdomain"
   crt -i domains.txt -s -e -json -o results.json
   crt -i domains.txt -c 100 -d 10 -jsonl
+  subfinder -d example.com | crt -s -q -jsonl
+
+Exit codes:
+  0  Results found (Bulk Mode: at least one lookup succeeded)
+  1  Invalid options, or the lookup failed (Bulk Mode: every lookup failed)
+  2  Invalid flag syntax
+  3  The lookup succeeded, but found no results [Single Domain Only]
+  124  -timeout exceeded
+  130  Interrupted
 `
 
-// Shared buffers for collecting results
+// State of the run
 var (
-	// Mutex to protect shared resources
-	fileMutex  sync.Mutex
-	resultsMux sync.Mutex
-	
-	// Buffers for collecting results
-	jsonResults  []json.RawMessage
-	jsonlResults []json.RawMessage
-	tableResults bytes.Buffer
-	csvResults   bytes.Buffer
-	
-	//Realpath for Output
-	absFilename string
+	// Compiled -match/-exclude patterns (nil = not set)
+	matchRe, excludeRe *regexp.Regexp
+	filterNames        bool
+
+	// Entry timestamp window of -since/-until (zero = no bound)
+	sinceTime, untilTime time.Time
+
+	// Expiry bound of -expiring (zero = no bound)
+	expiringBy time.Time
+
+	// Rendering options of the results, set from the flags
+	renderOpts result.Options
+
+	// Domain being looked up (empty in Bulk Mode)
+	queryDomain string
+
+	// Unique apex domains seen across all results (for the bulk summary)
+	apexes    = make(map[string]bool)
+	apexesMux sync.Mutex
 
 	// Flag to track if we're shutting down due to interrupt
 	shuttingDown bool
 	shutdownMux  sync.Mutex
-)
+	shutdownOnce sync.Once
+	outputOnce   sync.Once
 
-// logf prints messages only if quiet mode is disabled
-func logf(format string, args ...interface{}) {
-	if !*quietMode {
-		fmt.Fprintf(os.Stderr, format, args...)
-	}
-}
+	// Context of the whole run, cancelled on interrupt or -timeout
+	runCtx, cancelRun = context.WithCancel(context.Background())
+)
 
 func Execute() {
+	defer exitWithStatus()
+
 	initTime = time.Now()
 	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
 	flag.Parse()
-	
-	// Realpath to file
-    if *filename != "" {
-        absPath, err := filepath.Abs(*filename)
-        if err != nil {
-            absFilename = *filename // Fallback to original
-        } else {
-            absFilename = absPath
-        }
-
-		// Extract directory path and create missing directories
-		dir := filepath.Dir(absFilename)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			log.Fatalf("❌ Failed to create directories: %v", err)
+	if err := setupLogging(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *showVersion {
+		fmt.Println(versionString())
+		return
+	}
+	if *showSchema {
+		renderOpts.StringIDs = *jsonStrIDs
+		schema, err := renderOpts.Schema()
+		if err != nil {
+			logFatal("Failed to generate the JSON Schema", "error", err)
 		}
+		fmt.Println(string(schema))
+		return
+	}
+	setupRenderOptions()
 
-    // Check if file is not empty
-    if fileInfo, err := os.Stat(absFilename); err == nil && fileInfo.Size() > 0 {
-    	logf("⚠️ Warning: File %s is not empty. Clearing contents.\n", absFilename)
-    	if err := os.Truncate(absFilename, 0); err != nil {
-    		log.Fatalf("❌ Failed to clear file contents: %v", err)
-    	}
+	// Only seed explicitly, so the default stays time-based
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			repository.Seed(*seed)
 		}
-
-    logf("💾 Output will be saved to: %s\n", absFilename)
-    } else {
-        absFilename = ""
-    }
+	})
+	
+	validateFlags()
+	setupOutput()
 
 	// Set up signal handling for graceful shutdown
 	setupSignalHandling()
-	
-	// Validate incompatible output formats
-	if (*jsonOut && *csvOut) || (*jsonOut && *jsonlOut) || (*csvOut && *jsonlOut) {
-		fmt.Fprintln(os.Stderr, "❌ Error: Only one output format can be specified")
-		flag.Usage()
-		os.Exit(1)
+
+	// Periodically flush the output file to disk
+	if *flushEvery > 0 && *filename != "" {
+		stop := startFlusher(*flushEvery, syncOutput)
+		defer stop()
 	}
-	
-	// If input file is provided, perform bulk lookup
-	if *inputFile != "" {
+
+	var err error
+	if cache, err = openCache(); err != nil {
+		logFatal("Failed to open -cache database", "error", err)
+	}
+	defer cache.Close()
+
+	// Resolve a hostname list without touching the database
+	if *resolveOnly {
+		performResolveOnly()
+		return
+	}
+
+	// If input file is provided (or domains are piped in), perform bulk lookup
+	search, domain := certSearch()
+	if search == "" && !*crawlAll && (*inputFile != "" || (flag.NArg() == 0 && stdinPiped())) {
 		performBulkLookup()
 		return
 	}
 	
-	// Single domain lookup
-	if flag.NArg() != 1 {
-		flag.Usage()
-		os.Exit(1)
+	// Single domain lookup (or the certificate search, which takes its place)
+	if search == "" {
+		if flag.NArg() != 1 {
+			flag.Usage()
+			os.Exit(1)
+		}
+		domain = flag.Args()[0]
 	}
-	
-	domain := flag.Args()[0]
 	if domain == "" {
 		flag.Usage()
 		os.Exit(1)
 	}
+	queryDomain = domain
 
-	// Create a repository connection for single domain
-	repo, err := repository.New()
-	if err != nil {
-		log.Fatalf("❌ Failed to create repository: %v", err)
-	}
-	defer repo.Close()
+	// Create a client connection for single domain
+	client := newClient()
+	defer client.Close()
+
+	performLookup(client, domain)
+}
 
-	if err := lookupDomainWithRepo(repo, domain); err != nil {
-		log.Fatal(err)
+// performLookup looks up a single domain (or runs the certificate search)
+// and outputs its results
+func performLookup(client *crt.Client, domain string) {
+	if err := lookupDomainWithClient(client, domain, *limit, *subdomain); err != nil {
+		stopOnDeadline()
+		logFatal("Lookup failed", "domain", domain, "error", err)
 	}
-	
+	stopOnDeadline()
+
 	// Output final results for single domain
 	outputResults()
+
+	if !foundResults.Load() {
+		exitStatus = exitNoResults
+	}
+}
+
+// setupRenderOptions sets the rendering options of the results from the flags
+func setupRenderOptions() {
+	renderOpts.NoFooter = *noFooter
+	renderOpts.NoColor = !useColor()
+	renderOpts.Compact = *compact
+	renderOpts.Tree = *tree
+	renderOpts.Suspicious = *suspicious
+	renderOpts.Wildcard = *wildcard
+	renderOpts.Categorize = *categorize
+	renderOpts.Domain = *mergeOut
+	renderOpts.Verbose = *verbose
+	renderOpts.OwnCert = *ownCert
+	renderOpts.IPs = *resolveOnly || *resolve
+	renderOpts.RelativeTime = *relativeTime
+	renderOpts.DaysLeft = *expiringDays > 0
+	renderOpts.QueriedAt = *queriedAt
+	renderOpts.StringIDs = *jsonStrIDs
+	renderOpts.SerialFormat = *serialFormat
+	renderOpts.SANSummary = *sanSummary
+	renderOpts.NoHeader = *csvNoHeader
+	renderOpts.NRDDays = *nrdDays
+	renderOpts.MinResults = *minResults
+}
+
+// useColor decides whether tables get ANSI colors: -no-color and -force-color
+// win, otherwise only when results go to a terminal
+func useColor() bool {
+	if *noColor {
+		return false
+	}
+	if *forceColor {
+		return true
+	}
+	if *filename != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// dbHostList returns the -db-host values, or else the comma-separated hosts
+// of CRT_DB_HOST
+func dbHostList() []string {
+	if len(*dbHosts) > 0 {
+		return *dbHosts
+	}
+
+	var hosts []string
+	for _, h := range strings.Split(os.Getenv("CRT_DB_HOST"), ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts
+}
+
+// newClient connects to the database as configured by the flags
+func newClient() *crt.Client {
+	client, err := crt.New(crt.Config{
+		Backend: *backend,
+
+		Hosts:  dbHostList(),
+		Port:   *dbPort,
+		User:   envDefault(*dbUser, "CRT_DB_USER"),
+		DBName: envDefault(*dbName, "CRT_DB_NAME"),
+
+		SSLMode:     *dbSSLMode,
+		SSLRootCert: *dbRootCert,
+		SSLCert:     *dbSSLCert,
+		SSLKey:      *dbSSLKey,
+
+		QueryComment: *queryComment,
+		MaxIdle:      *maxIdle,
+
+		Filter: crt.Filter{
+			MinCertID: *minCertID,
+			MaxCertID: *maxCertID,
+			Since:     sinceTime,
+			Until:     untilTime,
+
+			ExpiringBy: expiringBy,
+		},
+
+		Log:    logger,
+		Output: renderOpts,
+	})
+	if err != nil {
+		logFatal("Failed to create repository", "error", err)
+	}
+	return client
+}
+
+// queryOptions returns the options of a query for at most limit results
+func queryOptions(limit int) crt.Options {
+	return crt.Options{Expired: *expired, Limit: limit}
 }
 
 // setupSignalHandling sets up handlers for interrupt signals
@@ -177,17 +525,65 @@ func setupSignalHandling() {
 	
 	go func() {
 		<-c
-		logf("\n⚠️ Interrupt received. Saving results and shutting down...\n")
-		
+		shutdown("Interrupt received, saving results and shutting down", 130) // Standard exit code for interrupt
+	}()
+
+	// Stop the whole run once -timeout is exceeded; the deadline is on the
+	// run's context, so it also reaches everything using it
+	if limit := runTimeout(); limit > 0 {
+		runCtx, cancelRun = context.WithTimeout(runCtx, limit)
+		go func() {
+			<-runCtx.Done()
+			stopOnDeadline()
+		}()
+	}
+}
+
+// stopOnDeadline shuts the run down with exit code 124 once the -timeout or
+// -max-runtime deadline is exceeded, and doesn't return then. The lookups cut
+// short by the deadline call it before reporting their error, so the run
+// always ends through shutdown, whichever notices the deadline first.
+func stopOnDeadline() {
+	if limit := runTimeout(); limit > 0 && errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		shutdown("Timeout exceeded, saving results and shutting down", 124, "timeout", limit)
+	}
+}
+
+// runTimeout returns the shorter of -timeout and -max-runtime (0 = none)
+func runTimeout() time.Duration {
+	if *timeout > 0 && (*maxRuntime <= 0 || *timeout < *maxRuntime) {
+		return *timeout
+	}
+	return max(*maxRuntime, 0)
+}
+
+// shutdown cancels the run, saves the results collected so far and exits,
+// logging reason (with its attributes) as a warning
+func shutdown(reason string, code int, args ...any) {
+	shutdownOnce.Do(func() {
+		logWarn(reason, args...)
+
 		shutdownMux.Lock()
 		shuttingDown = true
 		shutdownMux.Unlock()
-		
+		cancelRun()
+
 		// Save any collected results
 		outputResults()
-		
-		os.Exit(130) // Standard exit code for interrupt
-	}()
+
+		os.Exit(code)
+	})
+}
+
+// sleep waits for d, returning early if the run is cancelled
+func sleep(d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+	case <-runCtx.Done():
+	}
 }
 
 // isShuttingDown checks if we're in shutdown mode
@@ -197,387 +593,242 @@ func isShuttingDown() bool {
 	return shuttingDown
 }
 
-func lookupDomainWithRepo(repo *repository.Repository, domain string) error {
+func lookupDomainWithClient(client *crt.Client, domain string, limit int, subdomains bool) error {
+	res, err := fetchResults(client, domain, limit, subdomains)
+	if err != nil {
+		return err
+	}
+
+	// Process the results based on the output format
+	if res != nil {
+		if res.Size() > 0 {
+			foundResults.Store(true)
+		}
+		processResults(res, domain)
+	}
+	return nil
+}
+
+// fetchResults looks up at most limit results of domain (its subdomains if
+// subdomains, else its certificates) with retries, returning nil if nothing
+// was found
+func fetchResults(client *crt.Client, domain string, limit int, subdomains bool) (result.Printer, error) {
 	// Safety check to prevent index errors with some certificates 
 	if domain == "" {
-		return fmt.Errorf("❌ Empty Domain Name")
+		return nil, fmt.Errorf("empty domain name")
 	}
 	
 	// Don't start new lookups if we're shutting down
 	if isShuttingDown() {
-		return fmt.Errorf("shutdown in progress")
+		return nil, fmt.Errorf("shutdown in progress")
 	}
 	
 	for attempt := 0; attempt <= *retryCount; attempt++ {
 		// Check for shutdown between retry attempts
 		if attempt > 0 && isShuttingDown() {
-			return fmt.Errorf("interrupted")
+			return nil, fmt.Errorf("interrupted")
 		}
 		
-		// Add delay between retries
+		// Back off exponentially between retries
 		if attempt > 0 {
-			time.Sleep(time.Duration(*requestDelay) * time.Millisecond)
-			//logf("\nⓘ Retry %d for %s\n", attempt, domain)
+			sleep(withJitter(retryDelay(attempt), *retryJitter))
 		}
 
+		// Keep all workers together to one query per -d (plus any error backoff)
+		waitTurn()
+
 		var res result.Printer
 		var err error
 
-		if *subdomain {
-			res, err = repo.GetSubdomains(domain, *expired, *limit)
+		if subdomains {
+			res, err = querySubdomains(client, domain, limit)
 		} else {
-			res, err = repo.GetCertLogs(domain, *expired, *limit)
+			res, err = queryCertificates(client, domain, limit)
 		}
 
 		if err != nil {
+			recordFailure()
 			if attempt < *retryCount {
-				logf("\n❌ Error looking up %s: %v. Retrying (%d/%d)...\n", domain, err, attempt+1, *retryCount)
+				logError("Lookup failed, retrying", "domain", domain, "attempt", fmt.Sprintf("%d/%d", attempt+1, *retryCount), "error", err)
 				continue
 			}
-			return fmt.Errorf("❌ Lookup failed for %s after %d/%d attempts: %w", domain, *retryCount+1, *retryCount+1, err)
+			return nil, fmt.Errorf("lookup failed after %d attempts: %w", *retryCount+1, err)
 		}
+		recordSuccess()
 		
+		// Only trust an empty result once every attempt agrees
+		if res.Size() == 0 && *retryOnEmpty && attempt < *retryCount {
+			logInfo("No results, retrying", "domain", domain, "attempt", fmt.Sprintf("%d/%d", attempt+1, *retryCount))
+			continue
+		}
+
 		if res.Size() == 0 {
-			if !*jsonOut && !*jsonlOut {
-				logf("ⓘ Found no results for %s.\n", domain)
+			if !*jsonOut && !*jsonlOut && !*yamlOut {
+				logInfo("Found no results", "domain", domain)
+			}
+			// Still hand back the empty result, so headers/empty arrays (or a
+			// zero count) are written
+			if *emitEmpty || *countOnly {
+				return res, nil
 			}
-			return nil
+			return nil, nil
 		}
 		
-		// Process the results based on the output format
-		processResults(res, domain)
-		
-		return nil // Success
+		return res, nil // Success
 	}
 	
-	return fmt.Errorf("❌ Unexpected Error - Max Retries Exceeded")
+	return nil, fmt.Errorf("max retries exceeded")
 }
 
-func processResults(res result.Printer, domain string) {
-	if *jsonOut || *jsonlOut {
-		// Get JSON data
-		jsonData, err := res.JSON()
-		if err != nil {
-			logf("❌ Failed to format results as JSON for %s: %v\n", domain, err)
-			return
-		}
-		
-		resultsMux.Lock()
-		if *jsonOut {
-			// Parse the original array and add each item to our results
-			var items []json.RawMessage
-			if err := json.Unmarshal(jsonData, &items); err == nil {
-				jsonResults = append(jsonResults, items...)
-			} else {
-				logf("❌ Invalid JSON array for %s: %v\n", domain, err)
-			}
-		} else if *jsonlOut {
-			// For JSONL format, we need to parse the array and add each item separately
-			var items []json.RawMessage
-			if err := json.Unmarshal(jsonData, &items); err == nil {
-				for _, item := range items {
-					// Use Marshal to ensure each item is compact (no newlines)
-					compactJSON, err := json.Marshal(item)
-					if err == nil {
-						jsonlResults = append(jsonlResults, compactJSON)
-					} else {
-						logf("❌ Failed to marshal JSON item: %v\n", err)
-					}
-				}
-			} else {
-				logf("❌ Invalid JSON array for %s: %v\n", domain, err)
-			}
-		}
-		resultsMux.Unlock()
-		
-		// Direct output to file if specified
-		if *filename != "" {
-			fileMutex.Lock()
-			defer fileMutex.Unlock()
-			
-			flag := os.O_CREATE | os.O_WRONLY
-			if *jsonlOut {
-				flag |= os.O_APPEND // Append for JSONL
-			} else {
-				flag |= os.O_TRUNC // Truncate for JSON
-			}
-			
-			file, err := os.OpenFile(*filename, flag, 0644)
-			if err != nil {
-				logf("❌ Failed to open output file: %v\n", err)
-				return
-			}
-			defer file.Close()
-			
-			if *jsonlOut {
-       // For JSONL, write each item on a new line
-       var items []json.RawMessage
-       if err := json.Unmarshal(jsonData, &items); err == nil {
-         for _, item := range items {
-           // Use Marshal to ensure each item is compact (no newlines)
-           compactJSON, err := json.Marshal(item)
-           if err != nil {
-             logf("❌ Failed to marshal JSON item: %v\n", err)
-             continue
-           }
-           if _, err := file.Write(compactJSON); err != nil {
-             logf("❌ Failed to write to file: %v\n", err)
-           }
-           if _, err := file.Write([]byte("\n")); err != nil {
-             logf("❌ Failed to write newline to file: %v\n", err)
-           }
-         }
-       }
-			} else if *jsonOut {
-				// For JSON, we'll write a complete array at the end in outputResults
-			}
-		}
-	} else if *csvOut {
-		// CSV handling remains the same
-		csvData, err := res.CSV()
-		if err != nil {
-			logf("❌ Failed to format results as CSV for %s: %v\n", domain, err)
-			return
-		}
-		
-		resultsMux.Lock()
-		csvResults.Write(csvData)
-		csvResults.WriteString("\n")
-		resultsMux.Unlock()
-		
-		// Direct output to file if specified
-		if *filename != "" {
-			fileMutex.Lock()
-			defer fileMutex.Unlock()
-			
-			file, err := os.OpenFile(*filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-			if err != nil {
-				logf("❌ Failed to open output file: %v\n", err)
-				return
-			}
-			defer file.Close()
-			
-			if _, err := file.Write(csvData); err != nil {
-				logf("❌ Failed to write to file: %v\n", err)
-			}
-			file.WriteString("\n")
-		}
-	} else {
-		// Table format remains the same
-		tableData := res.Table()
-		
-		resultsMux.Lock()
-		tableResults.Write(tableData)
-		tableResults.WriteString("\n\n")
-		resultsMux.Unlock()
-		
-		// Direct output to file if specified
-		if *filename != "" {
-			fileMutex.Lock()
-			defer fileMutex.Unlock()
-			
-			file, err := os.OpenFile(*filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-			if err != nil {
-				logf("❌ Failed to open output file: %v\n", err)
-				return
-			}
-			defer file.Close()
-			
-			if _, err := file.Write(tableData); err != nil {
-				logf("❌ Failed to write to file: %v\n", err)
-			}
-			file.WriteString("\n\n")
-		}
+// querySubdomains looks up the subdomains of domain, then filters, flags
+// and resolves them as the flags ask
+func querySubdomains(client *crt.Client, domain string, limit int) (result.Printer, error) {
+	subs, err := getSubdomains(client, domain, limit)
+	if err != nil {
+		return nil, err
 	}
-}
 
-func outputResults() {
-	// Only output to stdout if no filename is specified
-	if *filename == "" {
-		if *jsonOut && len(jsonResults) > 0 {
-			// Create a single JSON array with all results
-			combinedJSON, err := json.MarshalIndent(jsonResults, "", "  ")
-			if err != nil {
-				logf("❌ Failed to combine JSON results: %v\n", err)
-				return
-			}
-			fmt.Println(string(combinedJSON))
-    } else if *jsonlOut && len(jsonlResults) > 0 {
-      // Output each JSON result on a separate line
-      for _, result := range jsonlResults {
-       fmt.Println(string(result))
-      }
-		} else if *csvOut && csvResults.Len() > 0 {
-			fmt.Print(csvResults.String())
-		} else if tableResults.Len() > 0 {
-			fmt.Print(tableResults.String())
-		}
-	} else if *jsonOut && len(jsonResults) > 0 {
-		// For JSON with filename, write the complete array at the end
-		combinedJSON, err := json.MarshalIndent(jsonResults, "", "  ")
-		if err != nil {
-			logf("❌ Failed to combine JSON results: %v\n", err)
-			return
-		}
-		
-		// Ensure the directory exists before writing the file
-		err = os.MkdirAll(filepath.Dir(*filename), 0755)
+	if filterNames {
+		subs = subs.FilterNames(matchRe, excludeRe)
+	}
+	if *wildcardOnly || *noWildcards {
+		subs = subs.FilterWildcards(*wildcardOnly)
+	}
+	if *suspicious {
+		subs.MarkSuspicious()
+	}
+	if *ownCert && len(subs) > 0 {
+		certs, err := getCertLogs(client, domain, limit)
 		if err != nil {
-			logf("❌ Failed to create directories: %v\n", err)
-			return
-		}
-
-		// Write the complete JSON array to the file
-		fileMutex.Lock()
-		defer fileMutex.Unlock()
-
-		if err := os.WriteFile(*filename, combinedJSON, 0644); err != nil {
-			logf("❌ Failed to write JSON to file: %v\n", err)
-			return
+			return nil, err
 		}
+		subs.MarkOwnCerts(certs)
 	}
-
-	// Always log if results were saved to a file
-	if *filename != "" {
-		if isShuttingDown() {
-			logf("✅ Saved partial results to %s before shutdown\n", absFilename)
-		} else {
-			logf("✅ Saved Results to %s\n", absFilename)
+	if *hostsFile || *resolve {
+		resolveSubdomains(subs)
+		if *resolvedOnly {
+			subs = subs.FilterResolved()
 		}
 	}
 
-	// Log time elapsed
-	elapsed := time.Since(initTime)
-	fmt.Fprintf(os.Stderr, "⌚ Finished in %s\n", elapsed.Round(time.Millisecond))
+	if *hostsFile {
+		return subs.HostEntries(), nil
+	}
+	return subs, nil
 }
 
-func performBulkLookup() {
-	// Check if input file exists
-	file, err := os.Open(*inputFile)
+// queryCertificates looks up the certificates of domain (or of the -serial,
+// -issuer or -org search), then filters or groups them as the flags ask
+func queryCertificates(client *crt.Client, domain string, limit int) (result.Printer, error) {
+	var certs result.Certificates
+	var err error
+	// Limit of the query the certificates came from, for telling NRD
+	nrdLimit, complete := limit, true
+	if *categorize {
+		var apex, subs result.Certificates
+		apex, subs, err = getCategorizedCertLogs(client, domain, limit)
+		certs = append(apex, subs...)
+	} else if *crawlAll {
+		// Every name is looked up on its own, so the crawl only has all
+		// certificates if none of the lookups reached the limit
+		certs, complete, err = crawlCertLogs(client, domain, limit)
+		nrdLimit = 0
+	} else if search, _ := certSearch(); search != "" {
+		certs, err = searchCertificates(client, search, domain, limit)
+	} else {
+		certs, err = getCertLogs(client, domain, limit)
+	}
 	if err != nil {
-		log.Fatalf("failed to open input file: %s", err)
+		return nil, err
 	}
-	defer file.Close()
-	
-	// Read domains from the file
-	var domains []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		domain := strings.TrimSpace(scanner.Text())
-		if domain != "" && !strings.HasPrefix(domain, "#") {
-			domains = append(domains, domain)
+
+	// Tell newly registered domains from all the certificates of the query,
+	// before filtering drops any
+	if complete {
+		certs.MarkNRD(renderOpts, nrdLimit)
+
+		// Don't let a young domain with too few certificates pass silently
+		if certs.NRDUnsure(renderOpts, nrdLimit, time.Now()) {
+			logWarn("Domain may be newly registered, but the results are too few to tell", "domain", domain, "results", len(certs), "min_results", *minResults)
 		}
 	}
-	
-	if err := scanner.Err(); err != nil {
-		log.Fatalf("❌ Error reading input file: %s", err)
+
+	if filterNames {
+		certs = certs.FilterNames(matchRe, excludeRe)
 	}
-	
-	if len(domains) == 0 {
-		fmt.Fprintln(os.Stderr, "No domains found in input file.")
-		os.Exit(1)
+	if *expiryGroups && len(certs) > 0 {
+		return certs.GroupByExpiry(domain, time.Now()), nil
 	}
-	
-	// Clear output file if it's specified and not in JSONL mode
-	if *filename != "" && !*jsonlOut {
-		if err := os.WriteFile(*filename, []byte{}, 0644); err != nil {
-			log.Fatalf("failed to clear output file: %s", err)
+	return certs, nil
+}
+
+// sortResults sorts certificates or subdomains by -sort and -reverse, before
+// any format renders them
+func sortResults(res result.Printer) {
+	switch res := res.(type) {
+	case result.Certificates:
+		if *sortBy == "" {
+			res.Sort(result.DefaultSortField, !*reverse)
+		} else {
+			res.Sort(*sortBy, *reverse)
 		}
+	case result.Subdomains:
+		res.Sort(*reverse)
 	}
-	
-	// Check for valid concurrency value
-	if *concurrent < 1 {
-		fmt.Fprintln(os.Stderr, "Warning: Invalid concurrency value. Setting to 1.")
-		*concurrent = 1
+}
+
+func processResults(res result.Printer, domain string) {
+	if certs, ok := res.(result.Certificates); ok && *expandSANs {
+		res = certs.ExpandSANs().Dedupe()
+	} else if ok && *dedupe {
+		res = certs.Dedupe()
 	}
 
-	// Create a single repository connection
-	repo, err := repository.New()
-	if err != nil {
-		log.Fatalf("❌ Failed to create repository: %v", err)
+	// With -uniq-across-domains, drop the subdomains another domain already had
+	if subs, ok := res.(result.Subdomains); ok && seenNames != nil {
+		if res = seenNames.Unseen(subs); res.Size() == 0 && !*emitEmpty && !*countOnly {
+			return
+		}
 	}
-	defer repo.Close()
 
-	// Process domains with limited concurrency
-	var wg sync.WaitGroup
-	semaphore := make(chan struct{}, *concurrent)
-	errorChannel := make(chan error, len(domains))
-	
-	// Track processed domains for status updates
-	var processedCount int32
-	var processedMutex sync.Mutex
-	totalDomains := len(domains)
-	
-	if !*quietMode {
-		fmt.Fprintf(os.Stderr, "ℹ️ Processing %d Domains (Concurrency:%d, Delay:%dms, Retries:%d) [Limit:%d]\n", 
-			len(domains), *concurrent, *requestDelay, *retryCount, *limit)
+	sortResults(res)
+
+	// With -diff, certificates are only output as changes at the end
+	if diffPrevious != nil && collectForDiff(res) {
+		return
 	}
-	
-	for _, domain := range domains {
-		// Don't start new lookups if we're shutting down
-		if isShuttingDown() {
-			break
-		}
-		
-		wg.Add(1)
-		semaphore <- struct{}{} // Acquire semaphore
-		go func(d string) {
-			defer wg.Done()
-			defer func() { <-semaphore }() // Release semaphore
-			
-			// Skip if we're shutting down
-			if isShuttingDown() {
-				return
-			}
-			
-			// Add configured delay between requests
-			time.Sleep(time.Duration(*requestDelay) * time.Millisecond)
-			
-			if err := lookupDomainWithRepo(repo, d); err != nil {
-				// Don't report errors during shutdown
-				if !isShuttingDown() {
-					errorChannel <- err
-					logf("❌ Error processing %s: %v\n", d, err)
-				}
-			}
-			
-			// Update progress counter
-			processedMutex.Lock()
-			processedCount++
-			progress := processedCount
-			processedMutex.Unlock()
-			
-			// Show progress periodically
-			if !*quietMode && !isShuttingDown() && progress%10 == 0 {
-				fmt.Fprintf(os.Stderr, "⏱️ Progress: %d/%d domains processed (%.1f%%)\n", 
-					progress, totalDomains, float64(progress)/float64(totalDomains)*100)
-			}
-		}(domain)
+
+	apexesMux.Lock()
+	for _, name := range result.Hostnames(res) {
+		apexes[result.ApexDomain(name)] = true
 	}
-	
-	wg.Wait()
-	close(errorChannel)
-	
-	// Check if there were any errors
-	errCount := 0
-	for err := range errorChannel {
-		if isShuttingDown() {
-			// Don't report errors during shutdown
-			continue
-		}
-		logf("❌ Error: %v\n", err)
-		errCount++
+	apexesMux.Unlock()
+
+	if stamper, ok := res.(result.Stamper); ok && *queriedAt {
+		stamper.Stamp(time.Now())
 	}
-	
-	// Output final results
-	outputResults()
-	
-	if !*quietMode && !isShuttingDown() {
-		elapsed := time.Since(initTime)
-		if errCount > 0 {
-			fmt.Fprintf(os.Stderr, "⚠️ Bulk lookup completed with %d errors in %s.\n", errCount, elapsed.Round(time.Millisecond))
+
+	if webhook != nil {
+		if jsonData, err := renderOpts.JSON(res); err != nil {
+			logError("Failed to format results as JSON", "domain", domain, "error", err)
 		} else {
-			fmt.Fprintf(os.Stderr, "\n✅ Bulk lookup completed successfully in %s.\n", elapsed.Round(time.Millisecond))
+			var items []json.RawMessage
+			if err := json.Unmarshal(jsonData, &items); err == nil {
+				webhook.Add(items...)
+			}
 		}
 	}
-}
\ No newline at end of file
+
+	// With -merge, the results of all domains are rendered together at the end
+	if mergeResults(res, domain) {
+		return
+	}
+
+	if domainFiles {
+		writeDomainFile(res, domain)
+		return
+	}
+
+	renderResults(res, domain)
+}
+
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBulkColor(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		ansi bool
+	}{
+		{"file", nil, false},
+		{"forced", []string{"-force-color"}, true},
+		{"no color wins", []string{"-force-color", "-no-color"}, false},
+		{"forced markdown", []string{"-force-color", "-md"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out := runBulk(t, &fakeAPI{}, "a.com\n", tt.args...)
+			if !strings.Contains(out, "a.com") {
+				t.Fatalf("no results in %q", out)
+			}
+			if got := strings.Contains(out, "\x1b["); got != tt.ansi {
+				t.Errorf("ANSI codes = %v, want %v:\n%q", got, tt.ansi, out)
+			}
+		})
+	}
+}
+
+func TestBulkRetryOnEmpty(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		certs   int
+		queries int
+	}{
+		{"empty, no retry", []string{"-r", "2"}, 0, 1},
+		{"empty, retried", []string{"-r", "2", "-retry-on-empty"}, 0, 3},
+		{"results, not retried", []string{"-r", "2", "-retry-on-empty"}, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeAPI{certs: map[string]int{"a.com": tt.certs}}
+			runBulk(t, api, "a.com\n", tt.args...)
+			if got := len(api.Queries()); got != tt.queries {
+				t.Errorf("a.com queried %d times, want %d", got, tt.queries)
+			}
+		})
+	}
+}
+
+func TestMaxRuntime(t *testing.T) {
+	// The deadline ends the process, so the run happens in a child process
+	if out := os.Getenv("CRT_TEST_MAX_RUNTIME_OUT"); out != "" {
+		api := &fakeAPI{delay: map[string]time.Duration{"slow.com": time.Minute}}
+		args := []string{"-json", "-max-runtime", "300ms", "-o", out, "-errors-file", out + ".errors"}
+		if os.Getenv("CRT_TEST_MAX_RUNTIME_MODE") == "single" {
+			runInput(t, func() {
+				setupSignalHandling()
+				queryDomain = "slow.com"
+				performLookup(newClient(), "slow.com")
+			}, api, "", args...)
+		} else {
+			runInput(t, func() {
+				setupSignalHandling()
+				performBulkLookup()
+			}, api, "a.com\nslow.com\nb.com\n", args...)
+		}
+		t.Fatal("the run outlived -max-runtime")
+	}
+
+	tests := []struct {
+		mode  string
+		saved []string // Common names of the results saved before the deadline
+	}{
+		{"bulk", []string{"a.com", "b.com"}},
+		{"single", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "out.json")
+			cmd := exec.Command(os.Args[0], "-test.run=^TestMaxRuntime$")
+			cmd.Env = append(os.Environ(), "CRT_TEST_MAX_RUNTIME_OUT="+out, "CRT_TEST_MAX_RUNTIME_MODE="+tt.mode)
+			start := time.Now()
+			output, err := cmd.CombinedOutput()
+
+			// Never the exit status 1 of a failed lookup or a run that returned
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) || exitErr.ExitCode() != 124 {
+				t.Fatalf("run ended with %v, want exit status 124:\n%s", err, output)
+			}
+			if elapsed := time.Since(start); elapsed > 10*time.Second {
+				t.Errorf("run took %s", elapsed)
+			}
+
+			// The lookup cut short isn't recorded as failed
+			if errs, _ := os.ReadFile(out + ".errors"); len(errs) > 0 {
+				t.Errorf("errors recorded: %q", errs)
+			}
+
+			// The results found before the deadline were saved
+			data, err := os.ReadFile(out)
+			if err != nil && !os.IsNotExist(err) {
+				t.Fatal(err)
+			}
+			var certs []struct {
+				CommonName string `json:"common_name"`
+			}
+			if len(data) > 0 {
+				if err := json.Unmarshal(data, &certs); err != nil {
+					t.Fatalf("invalid JSON %q: %v", data, err)
+				}
+			}
+			var names []string
+			for _, cert := range certs {
+				names = append(names, cert.CommonName)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.saved) {
+				t.Errorf("saved results of %v, want %v", names, tt.saved)
+			}
+		})
+	}
+}
+
+func TestBulkSortAcrossFormats(t *testing.T) {
+	// The name values of the certificates of a.com sort as text (www10
+	// before www2), unlike their IDs
+	api := &fakeAPI{certs: map[string]int{"a.com": 12}}
+	namePattern := regexp.MustCompile(`www(\d+)\.a\.com`)
+	want := []string{"0", "1", "10", "11", "2", "3", "4", "5", "6", "7", "8", "9"}
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"table", []string{"-no-color"}},
+		{"csv", []string{"-csv"}},
+		{"json", []string{"-json"}},
+		{"jsonl", []string{"-jsonl"}},
+		{"yaml", []string{"-yaml"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out := runBulk(t, api, "a.com\n", append([]string{"-l", "12", "-sort", "name_value"}, tt.args...)...)
+			var got []string
+			for _, match := range namePattern.FindAllStringSubmatch(out, -1) {
+				got = append(got, match[1])
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("certificates in order www%v, want www%v:\n%s", got, want, out)
+			}
+		})
+	}
+}
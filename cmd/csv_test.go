@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkgforge-security/crt/result"
+)
+
+func TestRenderResultsCSVHeader(t *testing.T) {
+	domains := []result.Certificates{
+		{{IssuerName: "C=US, O=\"DigiCert, Inc.\"", CommonName: "a.com", NameValue: "a.com\nwww.a.com", ID: 1, NotAfter: time.Now()}},
+		{{IssuerName: "C=US, O=Let's Encrypt", CommonName: "b.com", NameValue: "b.com", ID: 2, NotAfter: time.Now()}},
+		{{IssuerName: "C=US, O=Let's Encrypt", CommonName: "c.com", NameValue: "c.com\nwww.c.com", ID: 3, NotAfter: time.Now()}},
+	}
+
+	tests := []struct {
+		name     string
+		tsv      bool
+		noHeader bool
+		headers  int
+	}{
+		{"csv", false, false, 1},
+		{"csv without header", false, true, 0},
+		{"tsv", true, false, 1},
+		{"tsv without header", true, true, 0},
+	}
+
+	defer func(csvFlag, tsvFlag, noHeader bool, name string, opts result.Options) {
+		*csvOut, *tsvOut, *csvNoHeader, *filename, renderOpts = csvFlag, tsvFlag, noHeader, name, opts
+		csvResults.Reset()
+		csvWriter, csvHeaderDone = nil, false
+	}(*csvOut, *tsvOut, *csvNoHeader, *filename, renderOpts)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*csvOut, *tsvOut, *csvNoHeader = !tt.tsv, tt.tsv, tt.noHeader
+			*filename = filepath.Join(t.TempDir(), "out")
+			renderOpts = result.Options{NoHeader: tt.noHeader, NRDColumn: true}
+			csvResults.Reset()
+			csvHeaderDone = false
+			if tt.tsv {
+				csvWriter = result.NewTSVWriter(&csvResults)
+			} else {
+				csvWriter = csv.NewWriter(&csvResults)
+			}
+
+			for _, res := range domains {
+				renderResults(res, res[0].CommonName)
+			}
+
+			file, err := os.ReadFile(*filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(file, csvResults.Bytes()) {
+				t.Errorf("file differs from the collected output:\n%s\nwant:\n%s", file, csvResults.Bytes())
+			}
+
+			var rows [][]string
+			if tt.tsv {
+				for _, line := range strings.Split(strings.TrimSuffix(string(file), "\n"), "\n") {
+					rows = append(rows, strings.Split(line, "\t"))
+				}
+			} else if rows, err = csv.NewReader(bytes.NewReader(file)).ReadAll(); err != nil {
+				t.Fatalf("invalid CSV: %v\n%s", err, file)
+			}
+
+			headers := 0
+			for _, row := range rows {
+				if row[0] == "issuer_ca_id" {
+					headers++
+				}
+			}
+			if headers != tt.headers {
+				t.Errorf("got %d header rows, want %d:\n%s", headers, tt.headers, file)
+			}
+			if got, want := len(rows)-headers, len(domains); got != want {
+				t.Errorf("got %d rows, want %d:\n%s", got, want, file)
+			}
+		})
+	}
+}
+
+func TestCSVBOM(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		bom  bool
+	}{
+		{"csv", []string{"-csv"}, false},
+		{"csv with BOM", []string{"-csv", "-csv-bom"}, true},
+		{"tsv with BOM", []string{"-tsv", "-csv-bom"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out := runBulk(t, &fakeAPI{}, "a.com\nb.com\n", tt.args...)
+			if got := strings.HasPrefix(out, string(utf8BOM)); got != tt.bom {
+				t.Errorf("BOM = %v, want %v:\n%q", got, tt.bom, out)
+			}
+			if n := strings.Count(out, string(utf8BOM)); n > 1 {
+				t.Errorf("BOM written %d times:\n%q", n, out)
+			}
+			if !strings.Contains(out, "issuer_ca_id") {
+				t.Errorf("no CSV header:\n%q", out)
+			}
+		})
+	}
+}
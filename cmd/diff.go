@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pkgforge-security/crt/result"
+)
+
+var (
+	// Certificates of the previous run to compare against (-diff)
+	diffPrevious result.Certificates
+
+	// Certificates of this run, collected instead of being output directly
+	diffCurrent result.Certificates
+)
+
+// loadPreviousResults reads certificates saved by an earlier run with -json
+// (plain or -json-envelope) or -jsonl
+func loadPreviousResults(path string) (result.Certificates, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+
+	var certs result.Certificates
+	switch {
+	case len(data) == 0:
+		return result.Certificates{}, nil
+	case data[0] == '[':
+		err = json.Unmarshal(data, &certs)
+	case bytes.HasPrefix(data, []byte("{")) && bytes.Contains(data, []byte(`"results"`)) && !bytes.Contains(data, []byte("}\n{")):
+		var envelope struct {
+			Results result.Certificates `json:"results"`
+		}
+		err = json.Unmarshal(data, &envelope)
+		certs = envelope.Results
+	default:
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			var cert result.Certificate
+			if err := json.Unmarshal(line, &cert); err != nil {
+				return nil, fmt.Errorf("invalid JSONL line: %w", err)
+			}
+			certs = append(certs, cert)
+		}
+		err = scanner.Err()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a JSON/JSONL certificate result file: %w", path, err)
+	}
+	return certs, nil
+}
+
+// collectForDiff keeps certificate results for the final diff, reporting
+// whether res was consumed
+func collectForDiff(res result.Printer) bool {
+	certs, ok := res.(result.Certificates)
+	if !ok {
+		return false
+	}
+
+	resultsMux.Lock()
+	diffCurrent = append(diffCurrent, certs...)
+	resultsMux.Unlock()
+	return true
+}
+
+// writeDiff outputs the certificates added and removed since the previous run
+func writeDiff() {
+	resultsMux.Lock()
+	diff := result.Diff(diffPrevious, diffCurrent)
+	resultsMux.Unlock()
+
+	var data []byte
+	var err error
+	switch {
+	case *jsonOut:
+		data, err = renderOpts.JSON(diff)
+		data = append(data, '\n')
+	case *yamlOut:
+		data, err = renderOpts.YAML(diff)
+	case *csvOut:
+		data, err = renderOpts.CSV(diff)
+	case *tsvOut:
+		data, err = renderOpts.TSV(diff)
+	case *mdOut:
+		data = renderOpts.Markdown(diff)
+	case *htmlOut:
+		title := fmt.Sprintf("Changes since %s", *diffFile)
+		data, err = result.HTMLDocument(title, []result.HTMLSection{{Title: title, Table: renderOpts.HTML(diff)}})
+	default:
+		data = renderOpts.Table(diff)
+	}
+	if err != nil {
+		logError("Failed to format diff", "error", err)
+		return
+	}
+
+	logInfo("Compared with the previous results", "added", len(diff.Added), "removed", len(diff.Removed), "file", *diffFile)
+
+	if *filename == "" {
+		os.Stdout.Write(data)
+		return
+	}
+
+	fileMutex.Lock()
+	defer fileMutex.Unlock()
+	if err := os.WriteFile(*filename, data, 0644); err != nil {
+		logError("Failed to write diff to file", "file", *filename, "error", err)
+	}
+}
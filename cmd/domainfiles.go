@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkgforge-security/crt/result"
+)
+
+// domainPlaceholder in -o is replaced by each domain, writing its results to
+// a file of its own (e.g. results/{domain}.json)
+const domainPlaceholder = "{domain}"
+
+// domainFiles is set when -o contains domainPlaceholder
+var domainFiles bool
+
+// domainFilename returns the -o path of domain, which can't leave the
+// directory of the template
+func domainFilename(domain string) string {
+	name := strings.NewReplacer("/", "_", `\`, "_").Replace(domain)
+	if name == "" || name == "." || name == ".." {
+		name = "_"
+	}
+	return strings.ReplaceAll(*filename, domainPlaceholder, name)
+}
+
+// writeDomainFile writes the results of domain, on their own, to its file
+func writeDomainFile(res result.Printer, domain string) {
+	path := domainFilename(domain)
+
+	data, err := formatDomain(res, domain)
+	if err != nil {
+		logError("Failed to format results", "domain", domain, "error", err)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		logError("Failed to create directories", "file", path, "error", err)
+		return
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() > 0 && !*forceOut {
+		logError("Not writing the file, as it is not empty (use -force to replace its contents)", "file", path)
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		logError("Failed to write to file", "file", path, "error", err)
+		return
+	}
+	logInfo("Saved results", "domain", domain, "file", path, symbol("💾"))
+}
+
+// formatDomain renders res as a complete document of the output format
+func formatDomain(res result.Printer, domain string) ([]byte, error) {
+	switch {
+	case *jsonOut:
+		data, err := renderOpts.JSON(res)
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case *jsonlOut:
+		data, err := renderOpts.JSON(res)
+		if err != nil {
+			return nil, err
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, err
+		}
+		var lines bytes.Buffer
+		for _, item := range items {
+			if err := json.Compact(&lines, item); err != nil {
+				return nil, err
+			}
+			lines.WriteByte('\n')
+		}
+		return lines.Bytes(), nil
+	case *yamlOut:
+		return renderOpts.YAML(res)
+	case *countOnly:
+		unique := make(map[string]bool)
+		for _, name := range result.Hostnames(res) {
+			unique[name] = true
+		}
+		return []byte(fmt.Sprintf("%s\t%d\n", domain, len(unique))), nil
+	case *plainOut || *apexOut:
+		seen := make(map[string]bool)
+		var lines bytes.Buffer
+		for _, name := range result.Hostnames(res) {
+			if *apexOut {
+				name = result.ApexDomain(name)
+			}
+			if !seen[name] {
+				seen[name] = true
+				lines.WriteString(name + "\n")
+			}
+		}
+		return lines.Bytes(), nil
+	case *csvOut || *tsvOut:
+		var data []byte
+		var err error
+		if *tsvOut {
+			data, err = renderOpts.TSV(res)
+		} else {
+			data, err = renderOpts.CSV(res)
+		}
+		if err != nil || !*csvBOM {
+			return data, err
+		}
+		return append(append([]byte{}, utf8BOM...), data...), nil
+	case *htmlOut:
+		return result.HTMLDocument(domain, []result.HTMLSection{{Title: domain, Table: renderOpts.HTML(res)}})
+	case *mdOut:
+		return renderOpts.Markdown(res), nil
+	}
+	return renderOpts.Table(res), nil
+}
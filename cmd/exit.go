@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"os"
+	"sync/atomic"
+)
+
+// Exit codes, as listed in the usage
+const (
+	exitResults   = 0 // Results were found (or, in bulk mode, not every domain failed)
+	exitFailed    = 1 // Invalid options, or the lookup (every lookup in bulk mode) failed
+	exitNoResults = 3 // The lookup succeeded but found nothing
+)
+
+var (
+	// Whether any lookup returned results
+	foundResults atomic.Bool
+
+	// Code the run exits with once it is done
+	exitStatus = exitResults
+)
+
+// exitWithStatus ends the process with exitStatus; deferred first in Execute,
+// it runs after every other deferred cleanup. A shutdown in progress (or due
+// to the deadline) exits with its own code instead, once it saved the results.
+func exitWithStatus() {
+	stopOnDeadline()
+	if isShuttingDown() {
+		select {}
+	}
+	if exitStatus != exitResults {
+		os.Exit(exitStatus)
+	}
+}
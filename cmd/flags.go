@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stringList is a flag that may be repeated, collecting every value
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// listFlag defines a repeatable string flag, like flag.String does for one value
+func listFlag(name string) *stringList {
+	l := new(stringList)
+	flag.Var(l, name, "")
+	return l
+}
+
+// envDefault returns value, falling back to the environment variable key
+// when the flag was left empty
+func envDefault(value, key string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(key)
+}
+
+// parseSince parses a -since/-until value: a date (YYYY-MM-DD), an RFC3339
+// time, or a duration before now like 7d or 12h. Dates are midnight UTC;
+// with endOfDay, the midnight after them, so the whole day is included.
+func parseSince(value string, now time.Time, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return time.Time{}, fmt.Errorf("invalid number of days %q", value)
+		}
+		return now.AddDate(0, 0, -n), nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date (YYYY-MM-DD), RFC3339 time or duration (e.g. 7d, 12h)", value)
+}
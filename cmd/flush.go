@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"os"
+	"time"
+)
+
+// startFlusher calls flush (syncOutput) every interval until the returned
+// function is called, so a killed run loses at most one interval of output
+func startFlusher(interval time.Duration, flush func()) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				flush()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
+
+// syncOutput flushes everything written to the output file(s) to disk
+func syncOutput() {
+	if rotator != nil {
+		if err := rotator.Sync(); err != nil {
+			logError("Failed to flush output file", "error", err)
+		}
+		return
+	}
+	if stream != nil {
+		if err := stream.Sync(); err != nil {
+			logError("Failed to flush output file", "error", err)
+		}
+		return
+	}
+
+	fileMutex.Lock()
+	defer fileMutex.Unlock()
+
+	file, err := os.OpenFile(*filename, os.O_WRONLY, 0)
+	if os.IsNotExist(err) {
+		return
+	} else if err != nil {
+		logError("Failed to flush output file", "error", err)
+		return
+	}
+	defer file.Close()
+
+	if err := file.Sync(); err != nil {
+		logError("Failed to flush output file", "error", err)
+	}
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStartFlusher(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		run      time.Duration
+		atLeast  int32
+		atMost   int32
+	}{
+		{"flushes on the interval", 20 * time.Millisecond, 110 * time.Millisecond, 3, 6},
+		{"not before the interval", time.Second, 50 * time.Millisecond, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var flushes atomic.Int32
+			stop := startFlusher(tt.interval, func() { flushes.Add(1) })
+			time.Sleep(tt.run)
+			stop()
+
+			n := flushes.Load()
+			if n < tt.atLeast || n > tt.atMost {
+				t.Errorf("%d flushes in %s, want %d to %d", n, tt.run, tt.atLeast, tt.atMost)
+			}
+
+			// None after stop
+			time.Sleep(60 * time.Millisecond)
+			if after := flushes.Load(); after != n {
+				t.Errorf("%d flushes after stop", after-n)
+			}
+		})
+	}
+}
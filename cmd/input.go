@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// stdinPiped reports whether STDIN is a pipe or file rather than a terminal
+func stdinPiped() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
+
+// domainKey is the form domains are compared in: lower case, without a
+// trailing dot
+func domainKey(domain string) string {
+	return strings.ToLower(strings.TrimSuffix(domain, "."))
+}
+
+// dedupeDomains removes case-insensitive duplicates (ignoring a trailing dot),
+// keeping the first occurrence, and returns how many were removed
+func dedupeDomains(domains []string) ([]string, int) {
+	seen := make(map[string]bool, len(domains))
+	res := domains[:0]
+	for _, domain := range domains {
+		key := domainKey(domain)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		res = append(res, domain)
+	}
+	return res, len(domains) - len(res)
+}
+
+// parseShard parses a 1-based "i/n" shard specification
+func parseShard(spec string) (int, int, error) {
+	parts := strings.SplitN(spec, "/", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("expected i/n, got %q", spec)
+	}
+	index, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid shard index %q", parts[0])
+	}
+	total, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid shard count %q", parts[1])
+	}
+	if total < 1 || index < 1 || index > total {
+		return 0, 0, fmt.Errorf("shard %d/%d out of range", index, total)
+	}
+	return index, total, nil
+}
+
+// shardDomains returns the domains whose position modulo total matches the
+// 1-based shard index, so that all shards together cover the input exactly once
+func shardDomains(domains []string, index, total int) []string {
+	var res []string
+	for i, domain := range domains {
+		if i%total == index-1 {
+			res = append(res, domain)
+		}
+	}
+	return res
+}
+
+// domainOverride holds the settings a line of the input file gives its
+// domain instead of the flags, from "example.com 50" or a JSON object
+type domainOverride struct {
+	Domain    string `json:"domain"`
+	Limit     int    `json:"limit"`     // Instead of -l (0 = -l)
+	Subdomain *bool  `json:"subdomain"` // Instead of -s (nil = -s)
+}
+
+// readDomains reads one domain per line, skipping blank lines and # comments.
+// A line may give the domain its own result limit after it ("example.com 50"),
+// or be a JSON object ({"domain":"example.com","limit":50,"subdomain":true});
+// these are returned in overrides.
+func readDomains(r io.Reader) (domains []string, overrides map[string]domainOverride, err error) {
+	overrides = make(map[string]domainOverride)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(text, "{") {
+			var o domainOverride
+			if err := json.Unmarshal([]byte(text), &o); err != nil || strings.TrimSpace(o.Domain) == "" {
+				logWarn("Ignoring invalid JSON", "line", line)
+				continue
+			}
+			o.Domain = strings.TrimSpace(o.Domain)
+			if o.Limit < 0 {
+				logWarn("Ignoring invalid limit", "limit", o.Limit, "line", line)
+				o.Limit = 0
+			}
+			domains = append(domains, o.Domain)
+			overrides[o.Domain] = o
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		domain := fields[0]
+		domains = append(domains, domain)
+		if len(fields) > 1 {
+			if n, err := strconv.Atoi(fields[1]); err == nil && n > 0 {
+				overrides[domain] = domainOverride{Domain: domain, Limit: n}
+			} else {
+				logWarn("Ignoring invalid limit", "limit", fields[1], "line", line)
+			}
+		}
+	}
+	return domains, overrides, scanner.Err()
+}
+
+// domainLimit returns the input file's limit for domain, or else -l
+func domainLimit(overrides map[string]domainOverride, domain string) int {
+	if n := overrides[domain].Limit; n > 0 {
+		return n
+	}
+	return *limit
+}
+
+// domainSubdomain reports whether the subdomains of domain are enumerated:
+// as the input file says, or else -s
+func domainSubdomain(overrides map[string]domainOverride, domain string) bool {
+	if s := overrides[domain].Subdomain; s != nil {
+		return *s
+	}
+	return *subdomain
+}
+
+// mixesModes reports whether the input file enumerates the subdomains of
+// some domains but not of others
+func mixesModes(overrides map[string]domainOverride) bool {
+	for _, o := range overrides {
+		if o.Subdomain != nil && *o.Subdomain != *subdomain {
+			return true
+		}
+	}
+	return false
+}
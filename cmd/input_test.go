@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestShardDomains(t *testing.T) {
+	tests := []struct {
+		name    string
+		domains int
+		total   int
+	}{
+		{"even", 8, 4},
+		{"uneven", 10, 3},
+		{"more shards than domains", 2, 5},
+		{"single shard", 7, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			domains := make([]string, tt.domains)
+			for i := range domains {
+				domains[i] = fmt.Sprintf("d%d.com", i)
+			}
+
+			seen := make(map[string]int)
+			for index := 1; index <= tt.total; index++ {
+				shard := shardDomains(domains, index, tt.total)
+				if n := len(shard); n < tt.domains/tt.total || n > tt.domains/tt.total+1 {
+					t.Errorf("shard %d/%d has %d domains", index, tt.total, n)
+				}
+				for _, domain := range shard {
+					seen[domain]++
+				}
+			}
+
+			// Every domain is in exactly one shard
+			for _, domain := range domains {
+				if seen[domain] != 1 {
+					t.Errorf("%s is in %d shards", domain, seen[domain])
+				}
+			}
+		})
+	}
+}
+
+func TestParseShard(t *testing.T) {
+	tests := []struct {
+		spec         string
+		index, total int
+		wantErr      bool
+	}{
+		{"1/4", 1, 4, false},
+		{" 4 / 4 ", 4, 4, false},
+		{"0/4", 0, 0, true},
+		{"5/4", 0, 0, true},
+		{"1/0", 0, 0, true},
+		{"1", 0, 0, true},
+		{"a/b", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			index, total, err := parseShard(tt.spec)
+			if (err != nil) != tt.wantErr || index != tt.index || total != tt.total {
+				t.Errorf("parseShard(%q) = %d, %d, %v", tt.spec, index, total, err)
+			}
+		})
+	}
+}
+
+func TestDedupeDomains(t *testing.T) {
+	tests := []struct {
+		name    string
+		domains []string
+		want    []string
+		removed int
+	}{
+		{"no duplicates", []string{"a.com", "b.com"}, []string{"a.com", "b.com"}, 0},
+		{"exact duplicates", []string{"a.com", "b.com", "a.com", "a.com"}, []string{"a.com", "b.com"}, 2},
+		{"case and trailing dot", []string{"Example.com", "example.COM.", "b.com"}, []string{"Example.com", "b.com"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, removed := dedupeDomains(append([]string(nil), tt.domains...))
+			if !reflect.DeepEqual(got, tt.want) || removed != tt.removed {
+				t.Errorf("dedupeDomains(%v) = %v, %d, want %v, %d", tt.domains, got, removed, tt.want, tt.removed)
+			}
+		})
+	}
+}
+
+func TestBulkDuplicateInput(t *testing.T) {
+	input := "a.com\nb.com\nA.com\na.com.\nb.com\n"
+
+	tests := []struct {
+		name    string
+		args    []string
+		queries int
+	}{
+		{"deduped", nil, 2},
+		{"kept with -no-dedupe-input", []string{"-no-dedupe-input"}, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeAPI{}
+			runBulk(t, api, input, tt.args...)
+			if got := len(api.Queries()); got != tt.queries {
+				t.Errorf("%d queries (%v), want %d", got, api.Queries(), tt.queries)
+			}
+		})
+	}
+}
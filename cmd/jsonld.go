@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// jsonLDContext maps the certificate fields onto a crt.sh vocabulary, with
+// dates and links typed via schema.org
+var jsonLDContext = map[string]interface{}{
+	"@vocab":          "https://crt.sh/ns#",
+	"schema":          "https://schema.org/",
+	"entry_timestamp": map[string]string{"@type": "schema:DateTime"},
+	"not_before":      map[string]string{"@type": "schema:DateTime"},
+	"not_after":       map[string]string{"@type": "schema:DateTime"},
+	"issuer_name":     "schema:issuedBy",
+	"common_name":     "schema:name",
+}
+
+// jsonLDType is the @type given to each certificate record
+const jsonLDType = "X509Certificate"
+
+// combineJSONLD wraps the collected certificate records as a JSON-LD graph,
+// giving each record an @type and its crt.sh URL as @id
+func combineJSONLD(items []json.RawMessage) ([]byte, error) {
+	graph := make([]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		item = bytes.TrimSpace(item)
+		if len(item) < 2 || item[0] != '{' {
+			return nil, fmt.Errorf("unexpected JSON record: %s", item)
+		}
+
+		head := fmt.Sprintf(`{"@type":%q`, jsonLDType)
+		if id := jsonItemID(item); id != "" {
+			head += fmt.Sprintf(`,"@id":"https://crt.sh/?id=%s"`, bytes.Trim([]byte(id), `"`))
+		}
+		if !bytes.Equal(bytes.TrimSpace(item[1:]), []byte("}")) {
+			head += ","
+		}
+		graph = append(graph, append([]byte(head), item[1:]...))
+	}
+
+	doc := struct {
+		Context map[string]interface{} `json:"@context"`
+		Graph   []json.RawMessage      `json:"@graph"`
+	}{jsonLDContext, graph}
+	return json.MarshalIndent(doc, "", "  ")
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCombineJSONLD(t *testing.T) {
+	tests := []struct {
+		name    string
+		items   []json.RawMessage
+		ids     []string // @id of each node; "" for none
+		wantErr bool
+	}{
+		{"records", []json.RawMessage{json.RawMessage(`{"id":12,"common_name":"a.com"}`), json.RawMessage(` {"id":"34"} `)}, []string{"https://crt.sh/?id=12", "https://crt.sh/?id=34"}, false},
+		{"no id", []json.RawMessage{json.RawMessage(`{"common_name":"a.com"}`)}, []string{""}, false},
+		{"empty record", []json.RawMessage{json.RawMessage(`{}`)}, []string{""}, false},
+		{"no records", nil, nil, false},
+		{"not an object", []json.RawMessage{json.RawMessage(`[1]`)}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := combineJSONLD(tt.items)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("combineJSONLD() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var doc struct {
+				Context map[string]json.RawMessage   `json:"@context"`
+				Graph   []map[string]json.RawMessage `json:"@graph"`
+			}
+			if err := json.Unmarshal(data, &doc); err != nil {
+				t.Fatalf("invalid JSON-LD %s: %v", data, err)
+			}
+			if doc.Context["@vocab"] == nil || doc.Graph == nil {
+				t.Errorf("want @context with @vocab and an @graph array, got %s", data)
+			}
+			if len(doc.Graph) != len(tt.ids) {
+				t.Fatalf("@graph has %d nodes, want %d: %s", len(doc.Graph), len(tt.ids), data)
+			}
+			for i, node := range doc.Graph {
+				var typ, id string
+				json.Unmarshal(node["@type"], &typ)
+				json.Unmarshal(node["@id"], &id)
+				if typ != jsonLDType || id != tt.ids[i] {
+					t.Errorf("node %d has @type %q and @id %q, want %q and %q", i, typ, id, jsonLDType, tt.ids[i])
+				}
+			}
+		})
+	}
+}
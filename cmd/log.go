@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logLevels maps the names accepted by -log-level to their levels
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// symbolKey is the attribute replacing the symbol of the level in the human
+// format; the JSON format leaves it out
+const symbolKey = "symbol"
+
+var (
+	// logHandler prints the messages of the run and of the client, in the
+	// human format unless -log-json is set
+	logHandler slog.Handler = newHumanHandler(os.Stderr, slog.LevelInfo)
+
+	// logger logs through logHandler; it is also the logger of the client
+	logger = slog.New(logHandler)
+)
+
+// setupLogging applies -log-level (or -q, which only keeps errors) and
+// -log-json to the logger
+func setupLogging() error {
+	level, ok := logLevels[strings.ToLower(*logLevel)]
+	if !ok {
+		return fmt.Errorf("-log-level must be debug, info, warn or error, not %q", *logLevel)
+	}
+	if *quietMode {
+		level = slog.LevelError
+	}
+
+	if *logJSON {
+		logHandler = slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level, ReplaceAttr: dropSymbol})
+	} else {
+		logHandler = newHumanHandler(os.Stderr, level)
+	}
+	logger = slog.New(logHandler)
+	return nil
+}
+
+// logDebug, logInfo, logWarn and logError print msg at their level, if
+// -log-level (or -q) lets it through; args are key/value attributes:
+//
+//	logError("Failed to write to file", "file", absFilename, "error", err)
+func logDebug(msg string, args ...any) { logger.Debug(msg, args...) }
+func logInfo(msg string, args ...any)  { logger.Info(msg, args...) }
+func logWarn(msg string, args ...any)  { logger.Warn(msg, args...) }
+func logError(msg string, args ...any) { logger.Error(msg, args...) }
+
+// logSummary prints a closing message of the run (e.g. its duration) at
+// info level, even with -q or a higher -log-level
+func logSummary(msg string, args ...any) {
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, msg, 0)
+	record.Add(args...)
+	logHandler.Handle(context.Background(), record)
+}
+
+// logFatal prints msg as an error and exits
+func logFatal(msg string, args ...any) {
+	logError(msg, args...)
+	os.Exit(1)
+}
+
+// symbol replaces the symbol of the level in the human format, e.g. 💾 for
+// messages about the output file
+func symbol(s string) slog.Attr {
+	return slog.String(symbolKey, s)
+}
+
+// dropSymbol leaves the symbol attribute out of JSON messages
+func dropSymbol(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && a.Key == symbolKey {
+		return slog.Attr{}
+	}
+	return a
+}
+
+// levelSymbols mark each message in the human format
+var levelSymbols = map[slog.Level]string{
+	slog.LevelDebug: "⏳",
+	slog.LevelInfo:  "ℹ️",
+	slog.LevelWarn:  "⚠️ Warning:",
+	slog.LevelError: "❌",
+}
+
+// humanHandler prints messages as one friendly line each: the symbol of the
+// level, the message and its attributes, e.g.
+//
+//	❌ Failed to write to file (file: out.csv, error: disk full)
+//
+// Lines go above the progress bar, if one is drawn.
+type humanHandler struct {
+	w     io.Writer
+	level slog.Level
+	attrs []slog.Attr
+
+	// Shared with the handlers of WithAttrs, so the lines of concurrent
+	// lookups don't interleave
+	mu *sync.Mutex
+}
+
+// newHumanHandler returns a handler printing messages of level and above to w
+func newHumanHandler(w io.Writer, level slog.Level) *humanHandler {
+	return &humanHandler{w: w, level: level, mu: new(sync.Mutex)}
+}
+
+func (h *humanHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *humanHandler) Handle(_ context.Context, r slog.Record) error {
+	prefix := levelSymbols[r.Level]
+	var attrs []string
+	add := func(a slog.Attr) bool {
+		switch {
+		case a.Key == symbolKey:
+			prefix = a.Value.String()
+		case a.Key != "":
+			attrs = append(attrs, a.Key+": "+a.Value.Resolve().String())
+		}
+		return true
+	}
+	for _, a := range h.attrs {
+		add(a)
+	}
+	r.Attrs(add)
+
+	line := prefix + " " + r.Message
+	if len(attrs) > 0 {
+		line += " (" + strings.Join(attrs, ", ") + ")"
+	}
+	line += "\n"
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if progress != nil {
+		progress.Log(line)
+	} else {
+		fmt.Fprint(h.w, line)
+	}
+	return nil
+}
+
+func (h *humanHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &humanHandler{w: h.w, level: h.level, attrs: append(append([]slog.Attr{}, h.attrs...), attrs...), mu: h.mu}
+}
+
+// WithGroup is a no-op, as the human format doesn't qualify attributes
+func (h *humanHandler) WithGroup(string) slog.Handler {
+	return h
+}
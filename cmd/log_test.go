@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHumanLog(t *testing.T) {
+	tests := []struct {
+		name  string
+		level slog.Level
+		log   func()
+		want  string
+	}{
+		{"error with attributes", slog.LevelInfo, func() {
+			logError("Failed to write to file", "file", "out.csv", "error", errors.New("disk full"))
+		}, "❌ Failed to write to file (file: out.csv, error: disk full)\n"},
+		{"warning", slog.LevelInfo, func() { logWarn("Ignoring invalid JSON", "line", 3) }, "⚠️ Warning: Ignoring invalid JSON (line: 3)\n"},
+		{"symbol", slog.LevelInfo, func() { logInfo("Saved Results", "file", "out.csv", symbol("✅")) }, "✅ Saved Results (file: out.csv)\n"},
+		{"debug hidden", slog.LevelInfo, func() { logDebug("Queried GetCertLogs") }, ""},
+		{"info hidden by -q", slog.LevelError, func() { logInfo("Resolving Hostnames", "count", 2) }, ""},
+		{"summary kept by -q", slog.LevelError, func() {
+			logSummary("Finished", "elapsed", 1500*time.Millisecond, symbol("⌚"))
+		}, "⌚ Finished (elapsed: 1.5s)\n"},
+	}
+
+	defer func(h slog.Handler, l *slog.Logger) { logHandler, logger = h, l }(logHandler, logger)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			logHandler = newHumanHandler(&out, tt.level)
+			logger = slog.New(logHandler)
+
+			tt.log()
+			if out.String() != tt.want {
+				t.Errorf("got %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONLog(t *testing.T) {
+	defer func(h slog.Handler, l *slog.Logger) { logHandler, logger = h, l }(logHandler, logger)
+
+	var out bytes.Buffer
+	logHandler = slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelError, ReplaceAttr: dropSymbol})
+	logger = slog.New(logHandler)
+
+	logError("Failed to process domain", "domain", "example.com", "error", errors.New("timeout"), symbol("❌"))
+	logWarn("Hidden by the level")
+	logSummary("Total unique hostnames", "count", 42)
+
+	var lines []map[string]interface{}
+	for dec := json.NewDecoder(&out); dec.More(); {
+		var line map[string]interface{}
+		if err := dec.Decode(&line); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %v", len(lines), lines)
+	}
+
+	tests := []struct {
+		line  int
+		key   string
+		value interface{}
+	}{
+		{0, "level", "ERROR"},
+		{0, "domain", "example.com"},
+		{0, "error", "timeout"},
+		{0, symbolKey, nil},
+		{1, "level", "INFO"},
+		{1, "count", float64(42)},
+	}
+	for _, tt := range tests {
+		if got := lines[tt.line][tt.key]; got != tt.value {
+			t.Errorf("line %d: %s = %v, want %v", tt.line, tt.key, got, tt.value)
+		}
+	}
+}
+
+// overlapWriter records whether two writes ever ran at the same time
+type overlapWriter struct {
+	active  atomic.Int32
+	overlap atomic.Bool
+	lines   atomic.Int32
+}
+
+func (w *overlapWriter) Write(p []byte) (int, error) {
+	if w.active.Add(1) > 1 {
+		w.overlap.Store(true)
+	}
+	time.Sleep(time.Microsecond)
+	w.lines.Add(int32(bytes.Count(p, []byte("\n"))))
+	w.active.Add(-1)
+	return len(p), nil
+}
+
+func TestHumanLogConcurrent(t *testing.T) {
+	w := &overlapWriter{}
+	handler := newHumanHandler(w, slog.LevelInfo)
+	loggers := []*slog.Logger{slog.New(handler), slog.New(handler.WithAttrs([]slog.Attr{slog.String("worker", "1")}))}
+
+	const writes = 200
+	var wg sync.WaitGroup
+	for i := range writes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			loggers[i%len(loggers)].Error("Failed to process domain", "domain", "a.com")
+		}()
+	}
+	wg.Wait()
+
+	if w.overlap.Load() {
+		t.Error("lines were written concurrently")
+	}
+	if got := w.lines.Load(); got != writes {
+		t.Errorf("%d lines written, want %d", got, writes)
+	}
+}
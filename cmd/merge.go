@@ -0,0 +1,39 @@
+package cmd
+
+import "github.com/pkgforge-security/crt/result"
+
+// merged accumulates the results of every domain with -merge
+var merged result.Printer
+
+// mergeResults adds res to the merged results, tagged with domain, reporting
+// whether it did (only with -merge)
+func mergeResults(res result.Printer, domain string) bool {
+	if !*mergeOut {
+		return false
+	}
+
+	if setter, ok := res.(result.DomainSetter); ok {
+		setter.SetDomain(domain)
+	}
+
+	resultsMux.Lock()
+	defer resultsMux.Unlock()
+	if merged == nil {
+		merged = res
+	} else {
+		merged = merged.Merge(res)
+	}
+	return true
+}
+
+// renderMerged renders the merged results, once every domain is in
+func renderMerged() {
+	resultsMux.Lock()
+	res := merged
+	merged = nil
+	resultsMux.Unlock()
+
+	if res != nil {
+		renderResults(res, "all domains")
+	}
+}
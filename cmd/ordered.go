@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"sync"
+
+	"github.com/pkgforge-security/crt/result"
+)
+
+// orderedOutput buffers per-domain results of concurrent lookups and hands
+// them to processResults in input order, as soon as all earlier domains are done
+type orderedOutput struct {
+	mu      sync.Mutex
+	next    int
+	done    map[int]bool
+	pending map[int]orderedResult
+}
+
+type orderedResult struct {
+	domain string
+	res    result.Printer
+	err    error
+}
+
+var ordered *orderedOutput
+
+func newOrderedOutput() *orderedOutput {
+	return &orderedOutput{
+		done:    make(map[int]bool),
+		pending: make(map[int]orderedResult),
+	}
+}
+
+// Done records the outcome of the domain at index (res may be nil) and
+// flushes every consecutive result that is now ready
+func (o *orderedOutput) Done(index int, domain string, res result.Printer, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.done[index] = true
+	o.pending[index] = orderedResult{domain, res, err}
+
+	for o.done[o.next] {
+		if r, ok := o.pending[o.next]; ok {
+			r.process()
+			delete(o.pending, o.next)
+		}
+		delete(o.done, o.next)
+		o.next++
+	}
+}
+
+// FlushAll processes every buffered result, in order, regardless of gaps
+// left by domains that never finished (used on shutdown)
+func (o *orderedOutput) FlushAll() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for len(o.pending) > 0 {
+		if r, ok := o.pending[o.next]; ok {
+			r.process()
+			delete(o.pending, o.next)
+		}
+		o.next++
+	}
+}
+
+// process hands the result to processResults, or its error to
+// processFailure; a domain that succeeded is recorded in -resume after that
+func (r orderedResult) process() {
+	if r.err != nil {
+		processFailure(r.domain, r.err)
+		return
+	}
+	if r.res != nil {
+		processResults(r.res, r.domain)
+	}
+	resume.Record(r.domain)
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBulkOrdered(t *testing.T) {
+	domains := []string{"a.com", "b.com", "c.com", "d.com", "e.com"}
+
+	// Later domains answer first
+	api := &fakeAPI{
+		fail: map[string]bool{"c.com": true},
+		delay: map[string]time.Duration{
+			"a.com": 80 * time.Millisecond,
+			"b.com": 60 * time.Millisecond,
+			"c.com": 40 * time.Millisecond,
+			"d.com": 20 * time.Millisecond,
+		},
+	}
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"plain", []string{"-plain"}},
+		{"jsonl", []string{"-jsonl"}},
+		{"csv", []string{"-csv", "-csv-no-header"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out := runBulk(t, api, strings.Join(domains, "\n"), append([]string{"-ordered", "-c", "5"}, tt.args...)...)
+
+			// The first name of each domain, in the order they were output
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+				for _, domain := range domains {
+					if strings.Contains(line, domain) && (len(got) == 0 || got[len(got)-1] != domain) {
+						got = append(got, domain)
+					}
+				}
+			}
+			if want := []string{"a.com", "b.com", "d.com", "e.com"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("domains output in order %v, want %v:\n%s", got, want, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,764 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkgforge-security/crt/result"
+)
+
+// Shared buffers for collecting results
+var (
+	// Mutex to protect shared resources
+	fileMutex  sync.Mutex
+	resultsMux sync.Mutex
+
+	// UTF-8 byte order mark, prepended to CSV output with -csv-bom
+	utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+	// Buffers for collecting results
+	jsonResults  []json.RawMessage
+	jsonlResults []json.RawMessage
+	tableResults bytes.Buffer
+	csvResults   bytes.Buffer
+	yamlResults  bytes.Buffer
+	mdResults    bytes.Buffer
+	htmlResults  []result.HTMLSection
+	plainResults bytes.Buffer
+
+	// Writes the CSV (or TSV) of every domain into csvResults, so quoting
+	// and the header are handled once for the whole run
+	csvWriter result.RecordWriter
+
+	// Whether the CSV header was already collected (or written to the file)
+	csvHeaderDone bool
+
+	// Hostnames already in plainResults, so each is only printed once
+	plainSeen = make(map[string]bool)
+
+	// "domain<TAB>N" lines of -count, and the sum of all N
+	countResults bytes.Buffer
+	countTotal   int
+
+	//Realpath for Output
+	absFilename string
+)
+
+// setupOutput prepares the output file (-o) and the outputs written while
+// the run goes on (-rotate, -json-stream, -webhook), and loads the -diff file
+func setupOutput() {
+	// Realpath to file
+	if domainFiles {
+		absFilename = *filename
+		logInfo("Output will be saved to a file per domain", "file", absFilename, symbol("💾"))
+	} else if *filename != "" {
+		absPath, err := filepath.Abs(*filename)
+		if err != nil {
+			absFilename = *filename // Fallback to original
+		} else {
+			absFilename = absPath
+		}
+
+		// Extract directory path and create missing directories
+		dir := filepath.Dir(absFilename)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			logFatal("Failed to create directories", "error", err)
+		}
+
+		// Check if file is not empty
+		if *appendOut && *jsonlOut {
+			// Keep the file, but drop a line left incomplete by a killed run
+			if err := repairJSONL(absFilename); err != nil {
+				logFatal("Failed to check output file", "error", err)
+			}
+		} else if *appendOut {
+			// Keep the file; CSV added to it must not repeat the header
+			if fileInfo, err := os.Stat(absFilename); err == nil && fileInfo.Size() > 0 {
+				csvHeaderDone = true
+			}
+		} else if fileInfo, err := os.Stat(absFilename); err == nil && fileInfo.Size() > 0 && !*jsonAppend {
+			// Never destroy earlier results unless asked to
+			if !*forceOut {
+				logFatal("The output file is not empty, use -force to replace its contents or -append to add to them", "file", absFilename)
+			}
+			logWarn("The output file is not empty, clearing its contents", "file", absFilename)
+			if err := os.Truncate(absFilename, 0); err != nil {
+				logFatal("Failed to clear file contents", "error", err)
+			}
+		}
+
+		logInfo("Output will be saved", "file", absFilename, symbol("💾"))
+	} else {
+		absFilename = ""
+	}
+
+	if *tsvOut {
+		csvWriter = result.NewTSVWriter(&csvResults)
+	} else if *csvOut {
+		csvWriter = csv.NewWriter(&csvResults)
+	}
+
+	if *rotate > 0 {
+		rotator = newRotatingFile(absFilename, *rotate)
+	}
+
+	if *streamJSON {
+		var err error
+		if stream, err = newJSONStream(absFilename); err != nil {
+			logFatal("Failed to open output file", "error", err)
+		}
+	}
+
+	if *webhookURL != "" {
+		webhook = &webhookBatcher{url: *webhookURL, size: *webhookBatch, gzip: *webhookGzip}
+	}
+
+	if *diffFile != "" {
+		prev, err := loadPreviousResults(*diffFile)
+		if err != nil {
+			logFatal("Failed to load -diff file", "error", err)
+		}
+		diffPrevious = prev
+	}
+}
+
+// renderResults formats res in the output format, collecting it for STDOUT
+// or writing it to the output file
+func renderResults(res result.Printer, domain string) {
+	if *jsonOut || *jsonlOut {
+		// Get JSON data
+		jsonData, err := renderOpts.JSON(res)
+		if err != nil {
+			logError("Failed to format results as JSON", "domain", domain, "error", err)
+			return
+		}
+		if *jsonStatus {
+			jsonData = statusJSON(domain, jsonData, nil)
+		}
+		renderJSON(jsonData, domain)
+	} else if *yamlOut {
+		// Every result renders as a YAML list, so appending them builds one list
+		if res.Size() == 0 {
+			return
+		}
+		yamlData, err := renderOpts.YAML(res)
+		if err != nil {
+			logError("Failed to format results as YAML", "domain", domain, "error", err)
+			return
+		}
+
+		resultsMux.Lock()
+		yamlResults.Write(yamlData)
+		resultsMux.Unlock()
+	} else if *countOnly {
+		unique := make(map[string]bool)
+		for _, name := range result.Hostnames(res) {
+			unique[name] = true
+		}
+		line := fmt.Sprintf("%s\t%d\n", domain, len(unique))
+
+		resultsMux.Lock()
+		countResults.WriteString(line)
+		countTotal += len(unique)
+		resultsMux.Unlock()
+
+		if *filename != "" {
+			fileMutex.Lock()
+			defer fileMutex.Unlock()
+
+			file, err := os.OpenFile(*filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+			if err != nil {
+				logError("Failed to open output file", "error", err)
+				return
+			}
+			defer file.Close()
+
+			if _, err := file.WriteString(line); err != nil {
+				logError("Failed to write to file", "error", err)
+			}
+		}
+	} else if *plainOut || *apexOut {
+		// Only the names (or their apex domains) not printed yet, across all domains
+		var lines bytes.Buffer
+		resultsMux.Lock()
+		for _, name := range result.Hostnames(res) {
+			if *apexOut {
+				name = result.ApexDomain(name)
+			}
+			if !plainSeen[name] {
+				plainSeen[name] = true
+				lines.WriteString(name + "\n")
+			}
+		}
+		plainResults.Write(lines.Bytes())
+		resultsMux.Unlock()
+
+		if *filename != "" && lines.Len() > 0 {
+			fileMutex.Lock()
+			defer fileMutex.Unlock()
+
+			file, err := os.OpenFile(*filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+			if err != nil {
+				logError("Failed to open output file", "error", err)
+				return
+			}
+			defer file.Close()
+
+			if _, err := file.Write(lines.Bytes()); err != nil {
+				logError("Failed to write to file", "error", err)
+			}
+		}
+	} else if *csvOut || *tsvOut {
+		// Every domain goes through the one writer, and only the first
+		// writes the header, so bulk output has a single one
+		resultsMux.Lock()
+		before := csvResults.Len()
+		if err := renderOpts.WriteCSV(csvWriter, res, !csvHeaderDone && !*csvNoHeader); err != nil {
+			resultsMux.Unlock()
+			logError("Failed to format results as CSV", "domain", domain, "error", err)
+			return
+		}
+		csvHeaderDone = true
+		csvData := append([]byte{}, csvResults.Bytes()[before:]...)
+		// Take the file before letting go of the results, so the file gets
+		// the domains in the same order (header first)
+		if *filename != "" {
+			fileMutex.Lock()
+			defer fileMutex.Unlock()
+		}
+		resultsMux.Unlock()
+
+		// Direct output to file if specified
+		if *filename != "" {
+			file, err := os.OpenFile(*filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+			if err != nil {
+				logError("Failed to open output file", "error", err)
+				return
+			}
+			defer file.Close()
+
+			// Only the very first write into the file gets the BOM
+			if *csvBOM {
+				if info, err := file.Stat(); err == nil && info.Size() == 0 {
+					file.Write(utf8BOM)
+				}
+			}
+
+			if _, err := file.Write(csvData); err != nil {
+				logError("Failed to write to file", "error", err)
+			}
+		}
+	} else if *htmlOut {
+		// The page is only complete once every domain is in, so it's written at the end
+		resultsMux.Lock()
+		htmlResults = append(htmlResults, result.HTMLSection{Title: domain, Table: renderOpts.HTML(res)})
+		resultsMux.Unlock()
+	} else if *mdOut {
+		// Separate the tables of each domain, so they don't run together
+		mdData := append(renderOpts.Markdown(res), '\n')
+
+		resultsMux.Lock()
+		mdResults.Write(mdData)
+		resultsMux.Unlock()
+
+		if *filename != "" {
+			fileMutex.Lock()
+			defer fileMutex.Unlock()
+
+			file, err := os.OpenFile(*filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+			if err != nil {
+				logError("Failed to open output file", "error", err)
+				return
+			}
+			defer file.Close()
+
+			if _, err := file.Write(mdData); err != nil {
+				logError("Failed to write to file", "error", err)
+			}
+		}
+	} else {
+		// Table format remains the same
+		tableData := renderOpts.Table(res)
+
+		resultsMux.Lock()
+		tableResults.Write(tableData)
+		tableResults.WriteString("\n\n")
+		resultsMux.Unlock()
+
+		// Direct output to file if specified
+		if *filename != "" {
+			fileMutex.Lock()
+			defer fileMutex.Unlock()
+
+			file, err := os.OpenFile(*filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+			if err != nil {
+				logError("Failed to open output file", "error", err)
+				return
+			}
+			defer file.Close()
+
+			if _, err := file.Write(tableData); err != nil {
+				logError("Failed to write to file", "error", err)
+			}
+			file.WriteString("\n\n")
+		}
+	}
+}
+
+// renderJSON adds the JSON array of domain's results to the JSON or JSONL
+// output, or writes it to the output file
+func renderJSON(jsonData []byte, domain string) {
+	// Streamed JSON goes straight to the file instead of the buffer
+	if stream != nil {
+		var items []json.RawMessage
+		if err := json.Unmarshal(jsonData, &items); err != nil {
+			logError("Invalid JSON array", "domain", domain, "error", err)
+		} else if err := stream.Write(items); err != nil {
+			logError("Failed to write to file", "error", err)
+		}
+		return
+	}
+
+	resultsMux.Lock()
+	if *jsonOut {
+		// Parse the original array and add each item to our results
+		var items []json.RawMessage
+		if err := json.Unmarshal(jsonData, &items); err == nil {
+			jsonResults = append(jsonResults, items...)
+		} else {
+			logError("Invalid JSON array", "domain", domain, "error", err)
+		}
+	} else if *jsonlOut {
+		// For JSONL format, we need to parse the array and add each item separately
+		var items []json.RawMessage
+		if err := json.Unmarshal(jsonData, &items); err == nil {
+			for _, item := range items {
+				// Use Marshal to ensure each item is compact (no newlines)
+				compactJSON, err := json.Marshal(item)
+				if err == nil {
+					jsonlResults = append(jsonlResults, compactJSON)
+				} else {
+					logError("Failed to marshal JSON item", "error", err)
+				}
+			}
+		} else {
+			logError("Invalid JSON array", "domain", domain, "error", err)
+		}
+	}
+	resultsMux.Unlock()
+
+	// Write JSONL records into rotating files, -rotate records per file
+	if rotator != nil {
+		var items []json.RawMessage
+		if err := json.Unmarshal(jsonData, &items); err == nil {
+			for _, item := range items {
+				compactJSON, err := json.Marshal(item)
+				if err != nil {
+					logError("Failed to marshal JSON item", "error", err)
+					continue
+				}
+				if err := rotator.WriteRecord(compactJSON); err != nil {
+					logError("Failed to write to file", "error", err)
+				}
+			}
+		}
+		return
+	}
+
+	// Direct output to file if specified (appended JSON is merged at the end)
+	if *filename != "" && !*jsonAppend {
+		fileMutex.Lock()
+		defer fileMutex.Unlock()
+
+		flag := os.O_CREATE | os.O_WRONLY
+		if *jsonlOut {
+			flag |= os.O_APPEND // Append for JSONL
+		} else {
+			flag |= os.O_TRUNC // Truncate for JSON
+		}
+
+		file, err := os.OpenFile(*filename, flag, 0644)
+		if err != nil {
+			logError("Failed to open output file", "error", err)
+			return
+		}
+		defer file.Close()
+
+		if *jsonlOut {
+			// For JSONL, write each item on a new line
+			var items []json.RawMessage
+			if err := json.Unmarshal(jsonData, &items); err == nil {
+				for _, item := range items {
+					// Use Marshal to ensure each item is compact (no newlines)
+					compactJSON, err := json.Marshal(item)
+					if err != nil {
+						logError("Failed to marshal JSON item", "error", err)
+						continue
+					}
+					if _, err := file.Write(compactJSON); err != nil {
+						logError("Failed to write to file", "error", err)
+					}
+					if _, err := file.Write([]byte("\n")); err != nil {
+						logError("Failed to write newline to file", "error", err)
+					}
+				}
+			}
+		} else if *jsonOut {
+			// For JSON, we'll write a complete array at the end in outputResults
+		}
+	}
+}
+
+// outputResults writes the collected results once, whether the run finished
+// or was interrupted; a concurrent call waits for the first one to complete
+func outputResults() {
+	outputOnce.Do(writeResults)
+}
+
+// htmlDocument returns the collected HTML sections as a single page
+func htmlDocument() ([]byte, error) {
+	return result.HTMLDocument("crt.sh results", htmlResults)
+}
+
+// yamlDocument returns the collected YAML results as a single list, which
+// is empty when there are none
+func yamlDocument() []byte {
+	if yamlResults.Len() == 0 {
+		return []byte("[]\n")
+	}
+	return yamlResults.Bytes()
+}
+
+func writeResults() {
+	// Process results still waiting on earlier domains (on shutdown)
+	if ordered != nil {
+		ordered.FlushAll()
+	}
+
+	// Render -merge results now that every domain is in
+	renderMerged()
+
+	// Send whatever is left of the last webhook batch
+	if webhook != nil {
+		webhook.Flush()
+	}
+
+	// Only output to stdout if no filename is specified
+	if diffPrevious != nil {
+		writeDiff()
+	} else if domainFiles {
+		// Every domain's results are already in its own file
+	} else if *filename == "" {
+		// Snapshot the buffers while no lookup is still adding to them
+		resultsMux.Lock()
+		var out bytes.Buffer
+		if *jsonOut && (len(jsonResults) > 0 || *emitEmpty) {
+			// Create a single JSON array (or envelope) with all results
+			combinedJSON, err := combineJSONResults()
+			if err != nil {
+				resultsMux.Unlock()
+				logError("Failed to combine JSON results", "error", err)
+				return
+			}
+			out.Write(combinedJSON)
+			out.WriteString("\n")
+		} else if *jsonlOut && len(jsonlResults) > 0 {
+			// Output each JSON result on a separate line
+			for _, result := range jsonlResults {
+				out.Write(result)
+				out.WriteString("\n")
+			}
+		} else if *yamlOut && (yamlResults.Len() > 0 || *emitEmpty) {
+			out.Write(yamlDocument())
+		} else if *countOnly && countResults.Len() > 0 {
+			out.Write(countResults.Bytes())
+		} else if *htmlOut && (len(htmlResults) > 0 || *emitEmpty) {
+			data, err := htmlDocument()
+			if err != nil {
+				resultsMux.Unlock()
+				logError("Failed to render HTML report", "error", err)
+				return
+			}
+			out.Write(data)
+		} else if *mdOut && mdResults.Len() > 0 {
+			out.Write(mdResults.Bytes())
+		} else if (*plainOut || *apexOut) && plainResults.Len() > 0 {
+			out.Write(plainResults.Bytes())
+		} else if (*csvOut || *tsvOut) && csvResults.Len() > 0 {
+			if *csvBOM {
+				out.Write(utf8BOM)
+			}
+			out.Write(csvResults.Bytes())
+		} else if tableResults.Len() > 0 {
+			out.Write(tableResults.Bytes())
+		}
+		resultsMux.Unlock()
+		os.Stdout.Write(out.Bytes())
+
+		if *clip && out.Len() > 0 {
+			if err := copyToClipboard(out.Bytes()); err != nil {
+				logWarn("Could not copy results to clipboard", "error", err)
+			} else {
+				logInfo("Copied results to clipboard", symbol("📋"))
+			}
+		}
+	} else if stream != nil {
+		// Close the streamed array, also when interrupted
+		if err := stream.Close(); err != nil {
+			logError("Failed to write JSON to file", "error", err)
+		}
+	} else if *jsonAppend && len(jsonResults) > 0 {
+		// Merge into the array already in the file
+		resultsMux.Lock()
+		defer resultsMux.Unlock()
+		fileMutex.Lock()
+		defer fileMutex.Unlock()
+
+		if err := appendJSONFile(*filename, jsonResults, *jsonDedupe); err != nil {
+			logError("Failed to append JSON to file", "error", err)
+			return
+		}
+	} else if *yamlOut && (yamlResults.Len() > 0 || *emitEmpty) {
+		// Like JSON, the file gets the complete list at the end
+		resultsMux.Lock()
+		data := yamlDocument()
+		if *appendOut && yamlResults.Len() == 0 {
+			data = nil // An empty list ([]) can't be added to the one in the file
+		}
+		resultsMux.Unlock()
+
+		fileMutex.Lock()
+		defer fileMutex.Unlock()
+
+		// The lists of earlier runs and this one make up a single list
+		flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if *appendOut {
+			flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		file, err := os.OpenFile(*filename, flag, 0644)
+		if err != nil {
+			logError("Failed to open output file", "error", err)
+			return
+		}
+		defer file.Close()
+
+		if _, err := file.Write(data); err != nil {
+			logError("Failed to write YAML to file", "error", err)
+			return
+		}
+	} else if *htmlOut && (len(htmlResults) > 0 || *emitEmpty) {
+		resultsMux.Lock()
+		data, err := htmlDocument()
+		resultsMux.Unlock()
+		if err != nil {
+			logError("Failed to render HTML report", "error", err)
+			return
+		}
+
+		fileMutex.Lock()
+		defer fileMutex.Unlock()
+
+		if err := os.WriteFile(*filename, data, 0644); err != nil {
+			logError("Failed to write HTML to file", "error", err)
+			return
+		}
+	} else if *jsonOut && (len(jsonResults) > 0 || *emitEmpty) {
+		// For JSON with filename, write the complete array at the end
+		resultsMux.Lock()
+		combinedJSON, err := combineJSONResults()
+		resultsMux.Unlock()
+		if err != nil {
+			logError("Failed to combine JSON results", "error", err)
+			return
+		}
+
+		// Ensure the directory exists before writing the file
+		err = os.MkdirAll(filepath.Dir(*filename), 0755)
+		if err != nil {
+			logError("Failed to create directories", "error", err)
+			return
+		}
+
+		// Write the complete JSON array to the file
+		fileMutex.Lock()
+		defer fileMutex.Unlock()
+
+		if err := os.WriteFile(*filename, combinedJSON, 0644); err != nil {
+			logError("Failed to write JSON to file", "error", err)
+			return
+		}
+	}
+
+	if rotator != nil {
+		if err := rotator.Close(); err != nil {
+			logError("Failed to close output file", "error", err)
+		}
+	}
+
+	// Always log if results were saved to a file
+	if *filename != "" {
+		if isShuttingDown() {
+			logInfo("Saved partial results before shutdown", "file", absFilename, symbol("✅"))
+		} else if rotator != nil {
+			logInfo("Saved Results", "files", rotator.index, "file", absFilename, symbol("✅"))
+		} else if domainFiles {
+			logInfo("Saved Results to a file per domain", "file", absFilename, symbol("✅"))
+		} else {
+			logInfo("Saved Results", "file", absFilename, symbol("✅"))
+		}
+	}
+
+	// Log time elapsed
+	elapsed := time.Since(initTime)
+	logSummary("Finished", "elapsed", elapsed.Round(time.Millisecond), symbol("⌚"))
+}
+
+// queryMeta describes the parameters that produced a result set
+type queryMeta struct {
+	Domain         string    `json:"domain,omitempty"`
+	Input          string    `json:"input,omitempty"`
+	Subdomains     bool      `json:"subdomains"`
+	ExcludeExpired bool      `json:"exclude_expired"`
+	Limit          int       `json:"limit"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// combineJSONResults marshals the collected JSON results as a single array,
+// or as a {"query":{...},"results":[...]} envelope when -json-envelope is set
+func combineJSONResults() ([]byte, error) {
+	// Marshal no results as [] rather than null
+	if jsonResults == nil {
+		jsonResults = []json.RawMessage{}
+	}
+
+	if *jsonLD {
+		return combineJSONLD(jsonResults)
+	}
+
+	if !*jsonEnvelope {
+		return json.MarshalIndent(jsonResults, "", "  ")
+	}
+
+	envelope := struct {
+		Query   queryMeta         `json:"query"`
+		Results []json.RawMessage `json:"results"`
+	}{
+		Query: queryMeta{
+			Domain:         queryDomain,
+			Input:          *inputFile,
+			Subdomains:     *subdomain,
+			ExcludeExpired: *expired,
+			Limit:          *limit,
+			Timestamp:      initTime.UTC(),
+		},
+		Results: jsonResults,
+	}
+	return json.MarshalIndent(envelope, "", "  ")
+}
+
+// appendJSONFile merges items into the JSON array stored in path, optionally
+// skipping items whose "id" is already present, and replaces the file atomically
+func appendJSONFile(path string, items []json.RawMessage, dedupe bool) error {
+	var existing []json.RawMessage
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &existing); err != nil {
+			return fmt.Errorf("%s does not contain a JSON array: %w", path, err)
+		}
+	}
+
+	if dedupe {
+		seen := make(map[string]bool, len(existing))
+		for _, item := range existing {
+			if id := jsonItemID(item); id != "" {
+				seen[id] = true
+			}
+		}
+		for _, item := range items {
+			id := jsonItemID(item)
+			if id != "" && seen[id] {
+				continue
+			}
+			seen[id] = true
+			existing = append(existing, item)
+		}
+	} else {
+		existing = append(existing, items...)
+	}
+
+	merged, err := json.MarshalIndent(existing, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file next to the target, then rename over it
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(merged); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// repairJSONL truncates a JSONL file back to its last complete, valid line,
+// so appending after an interrupted write keeps the file valid
+func repairJSONL(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// Everything up to the last newline is complete; check what follows
+	end := bytes.LastIndexByte(data, '\n') + 1
+	tail := bytes.TrimSpace(data[end:])
+	if len(tail) == 0 {
+		return nil
+	}
+
+	if json.Valid(tail) {
+		// The last record is intact and only misses its newline
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = f.Write([]byte("\n"))
+		return err
+	}
+
+	logWarn("Dropping incomplete last line", "file", path)
+	return os.Truncate(path, int64(end))
+}
+
+// jsonItemID returns the "id" field of a JSON object, or "" if it has none
+func jsonItemID(item json.RawMessage) string {
+	var obj struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(item, &obj); err != nil || obj.ID == nil {
+		return ""
+	}
+	return string(obj.ID)
+}
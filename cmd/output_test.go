@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCombineJSONResultsEnvelope(t *testing.T) {
+	results := []json.RawMessage{json.RawMessage(`{"id":1}`), json.RawMessage(`{"id":2}`)}
+
+	tests := []struct {
+		name     string
+		envelope bool
+		results  []json.RawMessage
+		want     []string // top-level keys; nil for a plain array
+		count    int
+	}{
+		{"plain array", false, results, nil, 2},
+		{"envelope", true, results, []string{"query", "results"}, 2},
+		{"empty envelope", true, nil, []string{"query", "results"}, 0},
+	}
+
+	defer func(envelope bool, domain string, results []json.RawMessage, start time.Time) {
+		*jsonEnvelope, queryDomain, jsonResults, initTime = envelope, domain, results, start
+	}(*jsonEnvelope, queryDomain, jsonResults, initTime)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*jsonEnvelope, queryDomain, jsonResults = tt.envelope, "example.com", tt.results
+			initTime = time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local)
+
+			data, err := combineJSONResults()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.want == nil {
+				var array []json.RawMessage
+				if err := json.Unmarshal(data, &array); err != nil || len(array) != tt.count {
+					t.Errorf("got %s, want an array of %d results", data, tt.count)
+				}
+				return
+			}
+
+			var envelope map[string]json.RawMessage
+			if err := json.Unmarshal(data, &envelope); err != nil {
+				t.Fatalf("invalid envelope %s: %v", data, err)
+			}
+			var keys []string
+			for key := range envelope {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			if !reflect.DeepEqual(keys, tt.want) {
+				t.Errorf("envelope keys of %s, want %v", data, tt.want)
+			}
+
+			var query queryMeta
+			var array []json.RawMessage
+			if err := json.Unmarshal(envelope["query"], &query); err != nil || query.Domain != "example.com" || !query.Timestamp.Equal(initTime) {
+				t.Errorf("query = %s, want the domain and the start of the run", envelope["query"])
+			}
+			if err := json.Unmarshal(envelope["results"], &array); err != nil || array == nil || len(array) != tt.count {
+				t.Errorf("results = %s, want an array of %d results", envelope["results"], tt.count)
+			}
+		})
+	}
+}
+
+func TestAppendJSONFile(t *testing.T) {
+	items := []json.RawMessage{json.RawMessage(`{"id":2}`), json.RawMessage(`{"id":3}`)}
+
+	tests := []struct {
+		name     string
+		existing string // "" = no file
+		dedupe   bool
+		want     []int
+		wantErr  bool
+	}{
+		{"no file", "", false, []int{2, 3}, false},
+		{"empty file", " \n", false, []int{2, 3}, false},
+		{"pre-populated", `[{"id":1},{"id":2}]`, false, []int{1, 2, 2, 3}, false},
+		{"pre-populated, deduped", `[{"id":1},{"id":2}]`, true, []int{1, 2, 3}, false},
+		{"not an array", `{"id":1}`, false, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "results.json")
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := appendJSONFile(path, items, tt.dedupe)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("appendJSONFile() error = %v, want error %v", err, tt.wantErr)
+			}
+			data, _ := os.ReadFile(path)
+			if tt.wantErr {
+				if string(data) != tt.existing {
+					t.Errorf("file changed on error: %s", data)
+				}
+				return
+			}
+
+			var got []struct{ ID int }
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("invalid JSON array %s: %v", data, err)
+			}
+			var ids []int
+			for _, item := range got {
+				ids = append(ids, item.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("ids = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepairJSONL(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string // "" = no file
+		want     string
+	}{
+		{"no file", "", ""},
+		{"complete", "{\"id\":1}\n{\"id\":2}\n", "{\"id\":1}\n{\"id\":2}\n"},
+		{"truncated last line", "{\"id\":1}\n{\"id\":2}\n{\"id\":3,\"name_va", "{\"id\":1}\n{\"id\":2}\n"},
+		{"only a truncated line", "{\"id\":1,", ""},
+		{"missing final newline", "{\"id\":1}\n{\"id\":2}", "{\"id\":1}\n{\"id\":2}\n"},
+		{"trailing blanks", "{\"id\":1}\n  ", "{\"id\":1}\n  "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "results.jsonl")
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := repairJSONL(path); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil && !os.IsNotExist(err) {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("repaired file = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestBulkEmitEmpty(t *testing.T) {
+	api := &fakeAPI{certs: map[string]int{"empty.com": 0}}
+
+	tests := []struct {
+		name  string
+		args  []string
+		check func(t *testing.T, out string)
+	}{
+		{"json", []string{"-json"}, func(t *testing.T, out string) {
+			var results []json.RawMessage
+			if err := json.Unmarshal([]byte(out), &results); err != nil || results == nil || len(results) != 0 {
+				t.Errorf("want an empty JSON array, got %q", out)
+			}
+		}},
+		{"csv", []string{"-csv"}, func(t *testing.T, out string) {
+			rows, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+			if err != nil || len(rows) != 1 || rows[0][0] != "issuer_ca_id" {
+				t.Errorf("want only the CSV header, got %q", out)
+			}
+		}},
+		{"subdomains json", []string{"-json", "-s"}, func(t *testing.T, out string) {
+			if strings.TrimSpace(out) != "[]" {
+				t.Errorf("want an empty JSON array, got %q", out)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out := runBulk(t, api, "empty.com\n", append([]string{"-emit-empty"}, tt.args...)...)
+			tt.check(t, out)
+		})
+	}
+}
+
+func TestBulkQueriedAt(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"certificates", []string{"-json", "-queried-at"}},
+		{"subdomains", []string{"-json", "-queried-at", "-s"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now().Add(-time.Second) // RFC3339 drops the fraction
+			_, out := runBulk(t, &fakeAPI{}, "a.com\nb.com\n", tt.args...)
+
+			var records []struct {
+				QueriedAt string `json:"queried_at"`
+			}
+			if err := json.Unmarshal([]byte(out), &records); err != nil || len(records) == 0 {
+				t.Fatalf("want JSON records, got %q", out)
+			}
+			for _, record := range records {
+				stamp, err := time.Parse(time.RFC3339, record.QueriedAt)
+				if err != nil || stamp.Before(start) || stamp.After(time.Now()) {
+					t.Errorf("queried_at = %q, want a time since %s", record.QueriedAt, start.Format(time.RFC3339))
+				}
+			}
+		})
+	}
+}
+
+func TestBulkInterruptFlushesCSV(t *testing.T) {
+	// The interrupt ends the process, so the run happens in a child process
+	if os.Getenv("CRT_TEST_INTERRUPT") != "" {
+		api := &fakeAPI{delay: map[string]time.Duration{"slow.com": time.Minute}}
+		runInput(t, func() {
+			setupSignalHandling()
+			performBulkLookup()
+		}, api, "a.com\nslow.com\nb.com\n", "-csv", "-o", "")
+		t.Fatal("the run outlived the interrupt")
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.Command(os.Args[0], "-test.run=^TestBulkInterruptFlushesCSV$")
+	cmd.Env = append(os.Environ(), "CRT_TEST_INTERRUPT=1")
+	cmd.Stdout = &stdout
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Interrupt twice while slow.com is in flight; results are saved once
+	time.Sleep(500 * time.Millisecond)
+	cmd.Process.Signal(os.Interrupt)
+	cmd.Process.Signal(os.Interrupt)
+
+	var exitErr *exec.ExitError
+	if err := cmd.Wait(); !errors.As(err, &exitErr) || exitErr.ExitCode() != 130 {
+		t.Fatalf("run ended with %v, want exit status 130", err)
+	}
+
+	rows, err := csv.NewReader(&stdout).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV %q: %v", stdout.String(), err)
+	}
+	var names []string
+	for _, row := range rows {
+		names = append(names, row[3]) // common_name
+	}
+	sort.Strings(names)
+	if want := []string{"a.com", "b.com", "common_name"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("CSV rows of %v, want %v:\n%s", names, want, stdout.String())
+	}
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// progressBarWidth is the number of cells of the progress bar
+const progressBarWidth = 30
+
+// progressBar is the bulk mode status line on a terminal, redrawn in place
+// as domains complete; log messages are printed above it
+type progressBar struct {
+	mu    sync.Mutex
+	total int
+	start time.Time
+	line  string // Last drawn status, empty once finished
+}
+
+// progress is set while bulk mode draws a progress bar
+var progress *progressBar
+
+func newProgressBar(total int) *progressBar {
+	return &progressBar{total: total, start: time.Now()}
+}
+
+// stderrTerminal reports whether STDERR is a terminal rather than a pipe or file
+func stderrTerminal() bool {
+	info, err := os.Stderr.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Update redraws the bar with done of the total domains processed
+func (p *progressBar) Update(done, inFlight int, queued int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	filled := progressBarWidth * done / max(p.total, 1)
+	eta := "?"
+	if done > 0 {
+		remaining := time.Since(p.start) / time.Duration(done) * time.Duration(p.total-done)
+		eta = remaining.Round(time.Second).String()
+	}
+
+	p.line = fmt.Sprintf("⏱️ [%s%s] %d/%d (%.1f%%) ETA %s [In-flight: %d, Queued: %d]",
+		strings.Repeat("█", filled), strings.Repeat("░", progressBarWidth-filled),
+		done, p.total, float64(done)/float64(max(p.total, 1))*100, eta, inFlight, queued)
+	fmt.Fprint(os.Stderr, "\r\033[K"+p.line)
+}
+
+// Log prints msg in place of the bar, then draws the bar again below it
+func (p *progressBar) Log(msg string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.line == "" {
+		fmt.Fprint(os.Stderr, msg)
+		return
+	}
+	fmt.Fprint(os.Stderr, "\r\033[K"+msg)
+	if !strings.HasSuffix(msg, "\n") {
+		fmt.Fprintln(os.Stderr)
+	}
+	fmt.Fprint(os.Stderr, p.line)
+}
+
+// Finish ends the line of the bar, so later output starts below it; it is a
+// no-op without a bar
+func (p *progressBar) Finish() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.line != "" {
+		fmt.Fprintln(os.Stderr)
+		p.line = ""
+	}
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import "golang.org/x/time/rate"
+
+// limiter spaces out the requests of all workers, so that the overall rate
+// stays at one request per -d (plus any error backoff) whatever the concurrency
+var limiter = rate.NewLimiter(rate.Inf, 1)
+
+// waitTurn blocks until the caller's turn, or until the run is cancelled; a
+// delay of 0 never waits
+func waitTurn() {
+	limit := rate.Inf
+	if interval := effectiveDelay(); interval > 0 {
+		limit = rate.Every(interval)
+	}
+	if limiter.Limit() != limit {
+		limiter.SetLimit(limit)
+	}
+
+	// Only fails once the run is cancelled (or would be before the turn),
+	// which the caller notices on its own
+	_ = limiter.Wait(runCtx)
+}
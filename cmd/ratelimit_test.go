@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWaitTurn(t *testing.T) {
+	tests := []struct {
+		name    string
+		delay   int // -d in ms
+		calls   int
+		atLeast time.Duration
+		atMost  time.Duration
+	}{
+		{"no delay", 0, 5, 0, 50 * time.Millisecond},
+		{"spaced out", 20, 4, 60 * time.Millisecond, time.Second},
+	}
+
+	defer func(orig int) { *requestDelay = orig }(*requestDelay)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*requestDelay = tt.delay
+			time.Sleep(time.Duration(tt.delay) * time.Millisecond) // Let the previous turn pass
+
+			start := time.Now()
+			for range tt.calls {
+				waitTurn()
+			}
+			if elapsed := time.Since(start); elapsed < tt.atLeast || elapsed > tt.atMost {
+				t.Errorf("%d calls took %s, want between %s and %s", tt.calls, elapsed, tt.atLeast, tt.atMost)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkgforge-security/crt/result"
+)
+
+// resolveTimeout bounds a single DNS lookup
+const resolveTimeout = 5 * time.Second
+
+// lookupHost resolves a hostname to its addresses
+var lookupHost = net.DefaultResolver.LookupHost
+
+// resolveSubdomains fills in the IPs of each subdomain, with up to -c lookups
+// in flight, skipping wildcard names and leaving IPs empty for names that
+// don't resolve
+func resolveSubdomains(subs result.Subdomains) {
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, max(*concurrent, 1))
+
+	for i := range subs {
+		if strings.HasPrefix(subs[i].Name, "*") {
+			continue
+		}
+
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(sub *result.Subdomain) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			ctx, cancel := context.WithTimeout(runCtx, resolveTimeout)
+			defer cancel()
+
+			if ips, err := lookupHost(ctx, sub.Name); err == nil {
+				sub.IPs = ips
+				sub.Resolved = len(ips) > 0
+			}
+		}(&subs[i])
+	}
+	wg.Wait()
+}
+
+// performResolveOnly resolves the hostnames read from -i (or STDIN) and
+// outputs them as subdomains with their IPs
+func performResolveOnly() {
+	input := os.Stdin
+	if *inputFile != "" {
+		file, err := os.Open(*inputFile)
+		if err != nil {
+			logFatal("Failed to open input file", "error", err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	hosts, _, err := readDomains(input)
+	if err != nil {
+		logFatal("Failed to read hostnames", "error", err)
+	}
+	if len(hosts) == 0 {
+		logFatal("No hostnames found in input")
+	}
+
+	subs := make(result.Subdomains, len(hosts))
+	for i, host := range hosts {
+		subs[i] = result.Subdomain{Name: host}
+	}
+	subs.MarkWildcards()
+	if filterNames {
+		subs = subs.FilterNames(matchRe, excludeRe)
+	}
+	if *wildcardOnly || *noWildcards {
+		subs = subs.FilterWildcards(*wildcardOnly)
+	}
+
+	logInfo("Resolving Hostnames", "count", len(subs))
+	resolveSubdomains(subs)
+	if *resolvedOnly {
+		subs = subs.FilterResolved()
+	}
+
+	if *hostsFile {
+		processResults(subs.HostEntries(), "")
+	} else {
+		processResults(subs, "")
+	}
+	outputResults()
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestResolveOnly(t *testing.T) {
+	resolver := map[string][]string{
+		"www.example.com": {"192.0.2.1"},
+		"api.example.com": {"192.0.2.2", "2001:db8::2"},
+	}
+	defer func(orig func(context.Context, string) ([]string, error)) { lookupHost = orig }(lookupHost)
+	lookupHost = func(_ context.Context, host string) ([]string, error) {
+		if ips, ok := resolver[host]; ok {
+			return ips, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	input := "www.example.com\napi.example.com\ngone.example.com\n*.example.com\n"
+	tests := []struct {
+		name string
+		args []string
+		want map[string][]string
+	}{
+		{"all", nil, map[string][]string{
+			"www.example.com":  {"192.0.2.1"},
+			"api.example.com":  {"192.0.2.2", "2001:db8::2"},
+			"gone.example.com": nil,
+			"*.example.com":    nil,
+		}},
+		{"resolved only", []string{"-resolved-only"}, map[string][]string{
+			"www.example.com": {"192.0.2.1"},
+			"api.example.com": {"192.0.2.2", "2001:db8::2"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out := runInput(t, performResolveOnly, &fakeAPI{}, input, append([]string{"-resolve-only", "-json"}, tt.args...)...)
+
+			var subs []struct {
+				Name string   `json:"subdomain"`
+				IPs  []string `json:"ips"`
+			}
+			if err := json.Unmarshal([]byte(out), &subs); err != nil {
+				t.Fatalf("invalid JSON %q: %v", out, err)
+			}
+			got := make(map[string][]string, len(subs))
+			for _, sub := range subs {
+				got[sub.Name] = sub.IPs
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolved %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// rotatingFile writes records to path.1.ext, path.2.ext, ..., starting a new
+// file every max records
+type rotatingFile struct {
+	mu    sync.Mutex
+	path  string
+	max   int
+	index int
+	count int
+	file  *os.File
+}
+
+var rotator *rotatingFile
+
+func newRotatingFile(path string, max int) *rotatingFile {
+	return &rotatingFile{path: path, max: max}
+}
+
+// name returns the path of the index-th file, e.g. out.2.jsonl
+func (r *rotatingFile) name(index int) string {
+	ext := filepath.Ext(r.path)
+	return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(r.path, ext), index, ext)
+}
+
+// WriteRecord writes a single record (a line, without its newline)
+func (r *rotatingFile) WriteRecord(record []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil || r.count >= r.max {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := r.file.Write(append(record, '\n')); err != nil {
+		return err
+	}
+	r.count++
+	return nil
+}
+
+func (r *rotatingFile) rotate() error {
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			return err
+		}
+	}
+
+	r.index++
+	file, err := os.Create(r.name(r.index))
+	if err != nil {
+		return err
+	}
+	r.file, r.count = file, 0
+	return nil
+}
+
+// Sync flushes the current file to disk
+func (r *rotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Sync()
+}
+
+// Close closes the current file
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
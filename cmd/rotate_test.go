@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		max     int
+		records int
+		lines   []int // Per file
+	}{
+		{"below the boundary", 3, 2, []int{2}},
+		{"at the boundary", 3, 3, []int{3}},
+		{"just past the boundary", 3, 4, []int{3, 1}},
+		{"several files", 2, 5, []int{2, 2, 1}},
+		{"one per file", 1, 3, []int{1, 1, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			r := newRotatingFile(filepath.Join(dir, "out.jsonl"), tt.max)
+			for i := range tt.records {
+				if err := r.WriteRecord([]byte(fmt.Sprintf(`{"id":%d}`, i))); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := r.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			var lines []int
+			for i := 1; ; i++ {
+				data, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("out.%d.jsonl", i)))
+				if os.IsNotExist(err) {
+					break
+				} else if err != nil {
+					t.Fatal(err)
+				}
+				lines = append(lines, strings.Count(string(data), "\n"))
+			}
+			if !reflect.DeepEqual(lines, tt.lines) {
+				t.Errorf("lines per file = %v, want %v", lines, tt.lines)
+			}
+			if r.index != len(tt.lines) {
+				t.Errorf("index = %d, want %d", r.index, len(tt.lines))
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/pkgforge-security/crt/crt"
+	"github.com/pkgforge-security/crt/result"
+)
+
+// certSearch returns the certificate search that replaces the domain lookup
+// (-serial, -issuer or -org) and its value, or "" when looking up domains
+func certSearch() (kind, value string) {
+	switch {
+	case *serialNo != "":
+		return crt.SearchSerial, *serialNo
+	case *issuerQuery != "":
+		return crt.SearchIssuer, *issuerQuery
+	case *orgQuery != "":
+		return crt.SearchOrg, *orgQuery
+	}
+	return "", ""
+}
+
+// searchCertificates runs the certificate search of kind for value, served
+// from the cache when possible
+func searchCertificates(client *crt.Client, kind, value string, limit int) (result.Certificates, error) {
+	return cachedCertificates(cacheKey(kind, value, limit), func() (result.Certificates, error) {
+		return client.Search(runCtx, kind, value, queryOptions(limit))
+	})
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import "encoding/json"
+
+// domainStatus is the JSON of one domain with -json-status: its results, or
+// why its lookup failed
+type domainStatus struct {
+	Domain  string            `json:"domain"`
+	Error   string            `json:"error,omitempty"`
+	Results []json.RawMessage `json:"results"`
+}
+
+// statusJSON wraps the JSON array of domain's results (or its error) in a
+// one-element array holding its domainStatus
+func statusJSON(domain string, jsonData []byte, lookupErr error) []byte {
+	status := domainStatus{Domain: domain, Results: []json.RawMessage{}}
+	if lookupErr != nil {
+		status.Error = lookupErr.Error()
+	} else if err := json.Unmarshal(jsonData, &status.Results); err != nil {
+		status.Error = "invalid results: " + err.Error()
+	}
+
+	data, err := json.Marshal([]domainStatus{status})
+	if err != nil {
+		return []byte("[]")
+	}
+	return data
+}
+
+// processFailure outputs the failed lookup of domain with -json-status; the
+// other formats only log it. Lookups cut short by a shutdown aren't failures.
+func processFailure(domain string, err error) {
+	if *jsonStatus && !isShuttingDown() {
+		renderJSON(statusJSON(domain, nil, err), domain)
+	}
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// jsonStream writes a JSON array to a file item by item as results come in,
+// so memory stays flat and an interrupted run still leaves valid JSON
+type jsonStream struct {
+	mu     sync.Mutex
+	file   *os.File
+	count  int
+	closed bool
+}
+
+var stream *jsonStream
+
+// newJSONStream creates the file and opens the array
+func newJSONStream(path string) (*jsonStream, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.WriteString("["); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &jsonStream{file: file}, nil
+}
+
+// Write appends items to the array, indented like the buffered -json output
+func (s *jsonStream) Write(items []json.RawMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return os.ErrClosed
+	}
+
+	buf := new(bytes.Buffer)
+	for _, item := range items {
+		if s.count > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString("\n  ")
+		if err := json.Indent(buf, item, "  ", "  "); err != nil {
+			return err
+		}
+		s.count++
+	}
+
+	_, err := s.file.Write(buf.Bytes())
+	return err
+}
+
+// Sync flushes the array written so far to disk
+func (s *jsonStream) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	return s.file.Sync()
+}
+
+// Close terminates the array and closes the file; it is safe to call twice
+func (s *jsonStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	end := "]\n"
+	if s.count > 0 {
+		end = "\n]\n"
+	}
+	if _, err := s.file.WriteString(end); err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
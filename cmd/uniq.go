@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pkgforge-security/crt/result"
+)
+
+// nameSet is the set of subdomains already output by any domain of a bulk
+// run (-uniq-across-domains); it is safe for concurrent use
+type nameSet struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+// seenNames is set in bulk mode with -uniq-across-domains
+var seenNames *nameSet
+
+func newNameSet() *nameSet {
+	return &nameSet{seen: make(map[string]bool)}
+}
+
+// Unseen returns the subdomains of subs no earlier call has returned, and
+// adds them to the set. Names are compared like Subdomains.Dedupe does.
+func (s *nameSet) Unseen(subs result.Subdomains) result.Subdomains {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := make(result.Subdomains, 0, len(subs))
+	for _, sub := range subs {
+		key := strings.TrimSuffix(strings.ToLower(sub.Name), ".")
+		if s.seen[key] {
+			continue
+		}
+		s.seen[key] = true
+		res = append(res, sub)
+	}
+	return res
+}
@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkgforge-security/crt/crt"
+	"github.com/pkgforge-security/crt/result"
+)
+
+// serialPattern matches a serial number normalized to result.SerialHex
+var serialPattern = regexp.MustCompile(`^[0-9a-f]+$`)
+
+// usageError reports an invalid flag combination, prints the usage and exits
+func usageError(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "❌ Error: "+format+"\n", args...)
+	flag.Usage()
+	os.Exit(1)
+}
+
+// valueError reports an invalid flag value and exits
+func valueError(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "❌ Error: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// validateFlags exits on invalid flag values and combinations, and sets the
+// flags and values implied by the others (e.g. -json for -jsonld)
+func validateFlags() {
+	if *appendOut {
+		if *filename == "" || *htmlOut || *jsonLD || *jsonEnvelope || *streamJSON || *diffFile != "" {
+			usageError("-append requires -o, and cannot be used with -html, -jsonld, -json-envelope, -json-stream or -diff")
+		}
+		// For JSON, appending means merging into the array already in the file
+		if *jsonOut {
+			*jsonAppend = true
+		}
+	}
+
+	// With {domain} in -o, every domain is written to a file of its own
+	if strings.Contains(*filename, domainPlaceholder) {
+		if *mergeOut || *diffFile != "" || *appendOut || *jsonAppend || *streamJSON || *jsonEnvelope || *jsonStatus || *jsonLD || *rotate > 0 || *resolveOnly {
+			usageError("{domain} in -o cannot be used with -merge, -diff, -append, -json-append, -json-stream, -json-envelope, -json-status, -jsonld, -rotate or -resolve-only")
+		}
+		domainFiles = true
+	}
+
+	formats := 0
+	for _, set := range []bool{*jsonOut, *jsonlOut, *csvOut, *tsvOut, *jsonLD, *yamlOut, *mdOut, *htmlOut, *plainOut, *apexOut, *countOnly} {
+		if set {
+			formats++
+		}
+	}
+	if formats > 1 {
+		usageError("Only one output format can be specified")
+	}
+
+	// JSON-LD is collected like JSON and only wrapped differently at the end
+	if *jsonLD {
+		if *subdomain || *jsonEnvelope || *jsonAppend || *expiryGroups {
+			usageError("-jsonld only supports plain certificate results")
+		}
+		*jsonOut = true
+	}
+
+	if *retryJitter < 0 {
+		usageError("-retry-jitter must not be negative")
+	}
+	if *retryMaxWait < 0 {
+		usageError("-retry-max-delay must not be negative")
+	}
+
+	if *suspicious && !*subdomain {
+		usageError("-suspicious requires -s")
+	}
+	if (*wildcard || *wildcardOnly || *noWildcards) && !*subdomain && !*resolveOnly {
+		usageError("-wildcard, -wildcards-only and -no-wildcards require -s")
+	}
+	if *wildcardOnly && *noWildcards {
+		usageError("-wildcards-only cannot be used with -no-wildcards")
+	}
+	if *hostsFile && !*subdomain && !*resolveOnly {
+		usageError("-hosts-file requires -s or -resolve-only")
+	}
+	if *resolve && !*subdomain {
+		usageError("-resolve requires -s")
+	}
+	if *resolvedOnly && !*resolve && !*hostsFile && !*resolveOnly {
+		usageError("-resolved-only requires -resolve, -hosts-file or -resolve-only")
+	}
+	if *tree && !*subdomain && !*resolveOnly {
+		usageError("-tree requires -s")
+	}
+	if *ownCert && !*subdomain {
+		usageError("-own-cert requires -s")
+	}
+	if *categorize && *subdomain {
+		usageError("-categorize cannot be used with -s")
+	}
+	if *expiryGroups && *subdomain {
+		usageError("-expiry-groups cannot be used with -s")
+	}
+
+	if *csvBOM && !*csvOut {
+		usageError("-csv-bom requires -csv")
+	}
+	if *csvNoHeader && !*csvOut && !*tsvOut {
+		usageError("-csv-no-header requires -csv or -tsv")
+	}
+
+	if *webhookURL != "" && *webhookBatch < 1 {
+		usageError("-webhook-batch must be at least 1")
+	}
+
+	// Compile -match/-exclude once, before any query runs
+	matchRe = compilePattern("match", *matchRegex)
+	excludeRe = compilePattern("exclude", *excludeRegex)
+	filterNames = matchRe != nil || excludeRe != nil
+
+	if *since != "" {
+		var err error
+		if sinceTime, err = parseSince(*since, initTime, false); err != nil {
+			valueError("-since: %v", err)
+		}
+	}
+	if *until != "" {
+		var err error
+		if untilTime, err = parseSince(*until, initTime, true); err != nil {
+			valueError("-until: %v", err)
+		}
+	}
+	if !sinceTime.IsZero() && !untilTime.IsZero() && !sinceTime.Before(untilTime) {
+		valueError("-since (%s) must be before -until (%s)", sinceTime.Format(time.RFC3339), untilTime.Format(time.RFC3339))
+	}
+
+	if *expiringDays < 0 {
+		usageError("-expiring must be a positive number of days")
+	} else if *expiringDays > 0 {
+		expiringBy = initTime.AddDate(0, 0, *expiringDays)
+	}
+
+	if *minCertID < 0 || *maxCertID < 0 || (*maxCertID > 0 && *minCertID > *maxCertID) {
+		usageError("Invalid -min-cert-id/-max-cert-id range")
+	}
+
+	if *jsonAppend && (!*jsonOut || *jsonEnvelope || *filename == "") {
+		usageError("-json-append requires -json and -o, and cannot be used with -json-envelope")
+	}
+
+	if *rotate != 0 && (*rotate < 0 || !*jsonlOut || *filename == "" || *appendOut) {
+		usageError("-rotate requires a positive count, -jsonl and -o, and cannot be used with -append")
+	}
+
+	if *streamJSON && (!*jsonOut || *filename == "" || *jsonEnvelope || *jsonAppend || *jsonLD || *diffFile != "") {
+		usageError("-json-stream requires -json and -o, and cannot be used with -json-envelope, -json-append, -jsonld or -diff")
+	}
+
+	if *jsonDedupe && !*jsonAppend {
+		usageError("-json-append-dedupe requires -json-append")
+	}
+
+	if (*dedupe || *expandSANs) && *subdomain {
+		usageError("-dedupe and -expand cannot be used with -s (subdomains are always deduplicated)")
+	}
+
+	if *sortBy != "" {
+		if *subdomain && *sortBy != "name" {
+			usageError("-s results can only be sorted by name")
+		}
+		if err := result.ValidateFields([]string{*sortBy}); !*subdomain && err != nil {
+			valueError("-sort: %v", err)
+		}
+	}
+
+	if *fields != "" {
+		for _, field := range strings.Split(*fields, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				renderOpts.Fields = append(renderOpts.Fields, field)
+			}
+		}
+		if len(renderOpts.Fields) == 0 {
+			valueError("-fields: no field given (available: %s)", strings.Join(result.CertificateFields(), ","))
+		}
+		if err := result.ValidateFields(renderOpts.Fields); err != nil {
+			valueError("-fields: %v", err)
+		}
+		if !*csvOut && !*tsvOut && !*jsonOut && !*jsonlOut && !*yamlOut {
+			usageError("-fields requires -csv, -tsv, -json, -jsonl or -yaml")
+		}
+		if *subdomain {
+			usageError("-fields cannot be used with -s")
+		}
+	}
+
+	switch *dbSSLMode {
+	case "", "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		usageError("Invalid -db-sslmode %q", *dbSSLMode)
+	}
+
+	if *serialFormat != "" && *serialFormat != result.SerialHex && *serialFormat != result.SerialColon {
+		usageError("-serial-format must be %s or %s", result.SerialHex, result.SerialColon)
+	}
+
+	if *noColor && *forceColor {
+		usageError("-no-color and -force-color cannot be combined")
+	}
+
+	if *diffFile != "" && (*subdomain || *expiryGroups || *jsonlOut || *jsonLD || *jsonEnvelope || *jsonAppend || *appendOut || *rotate > 0) {
+		usageError("-diff only supports certificate results as a table, CSV, JSON or YAML")
+	}
+
+	switch *backend {
+	case crt.BackendAuto, crt.BackendDB, crt.BackendHTTP:
+	default:
+		usageError("-backend must be db, http or auto")
+	}
+
+	if *dbPort == 0 {
+		if env := os.Getenv("CRT_DB_PORT"); env != "" {
+			port, err := strconv.Atoi(env)
+			if err != nil {
+				valueError("Invalid CRT_DB_PORT %q", env)
+			}
+			*dbPort = port
+		}
+	}
+	if *dbPort < 0 || *dbPort > 65535 {
+		usageError("-db-port must be between 1 and 65535")
+	}
+
+	if *nrdDays < 1 {
+		usageError("-nrd-days must be at least 1")
+	}
+	if *minResults < 1 {
+		usageError("-min-results must be at least 1")
+	}
+
+	if *timeout < 0 || *maxRuntime < 0 {
+		usageError("-timeout must not be negative")
+	}
+	if *maxIdle < 0 {
+		usageError("-max-idle-before-reconnect must not be negative")
+	}
+
+	if *countOnly && (*expiryGroups || *hostsFile || *resolveOnly || *diffFile != "") {
+		usageError("-count cannot be used with -expiry-groups, -hosts-file, -resolve-only or -diff")
+	}
+	if (*plainOut || *apexOut) && (*expiryGroups || *hostsFile || *diffFile != "") {
+		usageError("-plain and -apex cannot be used with -expiry-groups, -hosts-file or -diff")
+	}
+
+	// A domain is only recorded as completed once its results are in -o,
+	// so outputs written at the end of the run can't be resumed
+	if *resumeFile != "" {
+		if *filename == "" || *jsonOut || *yamlOut || *htmlOut || *mergeOut || *diffFile != "" || *webhookURL != "" {
+			usageError("-resume requires -o, and cannot be used with -json, -yaml, -html, -merge, -diff or -webhook, which are only written at the end")
+		}
+		if !*appendOut && !domainFiles {
+			usageError("-resume requires -append (or {domain} in -o), so earlier results are kept")
+		}
+	}
+
+	if *mergeOut && (*jsonOut || *jsonlOut || *yamlOut || *plainOut || *apexOut || *countOnly || *diffFile != "") {
+		usageError("-merge only applies to the table, -csv, -tsv, -md and -html")
+	}
+
+	if *serialNo != "" && !serialPattern.MatchString(result.FormatSerial(*serialNo, result.SerialHex)) {
+		valueError("Invalid -serial %q, expected a hex serial number", *serialNo)
+	}
+
+	searches := 0
+	for _, value := range []string{*serialNo, *issuerQuery, *orgQuery} {
+		if value != "" {
+			searches++
+		}
+	}
+	if searches > 1 {
+		usageError("Only one of -serial, -issuer and -org can be specified")
+	}
+	if searches > 0 && (*subdomain || *categorize || *expiryGroups || *inputFile != "" || flag.NArg() > 0) {
+		usageError("-serial, -issuer and -org cannot be used with -s, -categorize, -expiry-groups, -i or a domain")
+	}
+
+	if *crawlAll && (*subdomain || *categorize || searches > 0 || *inputFile != "") {
+		usageError("-all looks up a single domain, and cannot be used with -s, -categorize, -serial, -issuer, -org or -i")
+	}
+
+	if *cacheTTL <= 0 {
+		usageError("-cache-ttl must be positive")
+	}
+
+	if *jsonEnvelope && !*jsonOut {
+		usageError("-json-envelope requires -json")
+	}
+
+	if *jsonStatus {
+		if (!*jsonOut && !*jsonlOut) || *jsonLD || *jsonDedupe {
+			usageError("-json-status requires -json or -jsonl, and cannot be used with -jsonld or -json-append-dedupe")
+		}
+		// A domain without results is listed with an empty array
+		*emitEmpty = true
+	}
+}
+
+// compilePattern compiles the regular expression of a flag, exiting on errors;
+// an empty pattern gives nil
+func compilePattern(name, pattern string) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		valueError("Invalid -%s pattern: %v", name, err)
+	}
+	return re
+}
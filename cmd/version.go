@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Build metadata, injected at build time with
+//
+//	go build -ldflags "-X github.com/pkgforge-security/crt/cmd.version=v1.2.3 \
+//	  -X github.com/pkgforge-security/crt/cmd.commit=$(git rev-parse HEAD) \
+//	  -X github.com/pkgforge-security/crt/cmd.date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	version = "dev"
+	commit  = ""
+	date    = ""
+)
+
+// versionString describes the build, falling back to the VCS details Go
+// embeds in the binary for whatever wasn't injected
+func versionString() string {
+	rev, built := commit, date
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && rev == "":
+				rev = setting.Value
+			case setting.Key == "vcs.time" && built == "":
+				built = setting.Value
+			}
+		}
+	}
+	if rev == "" {
+		rev = "unknown"
+	}
+	if built == "" {
+		built = "unknown"
+	}
+	return fmt.Sprintf("crt %s (commit: %s, built: %s)", version, rev, built)
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// webhookClient is used for all POSTs to -webhook
+var webhookClient = &http.Client{Timeout: 30 * time.Second}
+
+// webhookBatcher collects result records and POSTs them to -webhook as JSON
+// arrays of -webhook-batch records
+type webhookBatcher struct {
+	mu      sync.Mutex
+	url     string
+	size    int
+	gzip    bool
+	pending []json.RawMessage
+}
+
+var webhook *webhookBatcher
+
+// Add queues records, sending every batch that fills up. Batches are taken
+// out under the lock and POSTed after releasing it, so a slow endpoint does
+// not block other workers from queueing results
+func (w *webhookBatcher) Add(items ...json.RawMessage) {
+	for _, batch := range w.take(items) {
+		w.send(batch)
+	}
+}
+
+// take queues items and removes every full batch from the queue
+func (w *webhookBatcher) take(items []json.RawMessage) [][]json.RawMessage {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var batches [][]json.RawMessage
+	w.pending = append(w.pending, items...)
+	for len(w.pending) >= w.size {
+		batches = append(batches, w.pending[:w.size:w.size])
+		w.pending = w.pending[w.size:]
+	}
+	return batches
+}
+
+// Flush sends the final partial batch, if any
+func (w *webhookBatcher) Flush() {
+	w.mu.Lock()
+	batch := w.pending
+	w.pending = nil
+	w.mu.Unlock()
+
+	if len(batch) > 0 {
+		w.send(batch)
+	}
+}
+
+// send POSTs a single batch, retrying up to -r times until the run is
+// cancelled. The final flush on shutdown still gets its first attempt
+func (w *webhookBatcher) send(batch []json.RawMessage) {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		logError("Failed to marshal webhook batch", "error", err)
+		return
+	}
+
+	if w.gzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(body); err != nil {
+			logError("Failed to compress webhook batch", "error", err)
+			return
+		}
+		if err := zw.Close(); err != nil {
+			logError("Failed to compress webhook batch", "error", err)
+			return
+		}
+		body = buf.Bytes()
+	}
+
+	for attempt := 0; attempt <= *retryCount; attempt++ {
+		if attempt > 0 {
+			sleep(withJitter(effectiveDelay(), *retryJitter))
+			if runCtx.Err() != nil {
+				break
+			}
+		}
+
+		if err = w.post(body); err == nil {
+			return
+		}
+	}
+	logError("Failed to send records to webhook", "records", len(batch), "attempts", *retryCount+1, "error", err)
+}
+
+func (w *webhookBatcher) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
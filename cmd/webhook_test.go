@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestWebhookBatcher(t *testing.T) {
+	tests := []struct {
+		name  string
+		size  int
+		adds  []int
+		sizes []int
+	}{
+		{"exact batches", 2, []int{2, 2}, []int{2, 2}},
+		{"partial final batch", 3, []int{4}, []int{3, 1}},
+		{"batch spans adds", 3, []int{1, 1, 1, 1}, []int{3, 1}},
+		{"only flush", 5, []int{2}, []int{2}},
+		{"nothing to send", 5, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu    sync.Mutex
+				sizes []int
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var batch []json.RawMessage
+				if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+					t.Errorf("decoding batch: %v", err)
+				}
+				mu.Lock()
+				sizes = append(sizes, len(batch))
+				mu.Unlock()
+			}))
+			defer srv.Close()
+
+			w := &webhookBatcher{url: srv.URL, size: tt.size}
+			n := 0
+			for _, count := range tt.adds {
+				var items []json.RawMessage
+				for range count {
+					n++
+					items = append(items, json.RawMessage(strconv.Itoa(n)))
+				}
+				w.Add(items...)
+			}
+			w.Flush()
+
+			if !reflect.DeepEqual(sizes, tt.sizes) {
+				t.Errorf("batch sizes = %v, want %v", sizes, tt.sizes)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+// Package crt looks up certificate transparency logs on crt.sh, for use in
+// other Go programs. The crt command is a thin wrapper over it.
+//
+//	client, err := crt.New(crt.Config{})
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+//
+//	certs, err := client.Lookup(ctx, "example.com", crt.Options{Limit: 50})
+//	table, err := client.Render(certs, crt.FormatTable)
+package crt
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/pkgforge-security/crt/repository"
+	"github.com/pkgforge-security/crt/result"
+)
+
+// DefaultLimit is the number of results returned when Options.Limit is 0
+const DefaultLimit = 10
+
+// Searches selectable with Client.Search, which look up certificates by
+// something else than a domain
+const (
+	SearchSerial = "serial" // Serial number, as hex with or without colons
+	SearchIssuer = "issuer" // Part of the issuer name (database backend only)
+	SearchOrg    = "org"    // Organization of the subject (O=)
+)
+
+// Backends selectable with Config.Backend
+const (
+	BackendAuto = repository.BackendAuto // The database, or the JSON API if it is unavailable
+	BackendDB   = repository.BackendDB   // The crt.sh database only
+	BackendHTTP = repository.BackendHTTP // The crt.sh JSON API only
+)
+
+// Formats of Client.Render
+const (
+	FormatTable    = "table"
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+	FormatCSV      = "csv"
+	FormatTSV      = "tsv"
+	FormatJSON     = "json"
+	FormatYAML     = "yaml"
+)
+
+// Config holds the settings of a Client; the zero value queries crt.sh
+type Config struct {
+	// Backend is one of BackendAuto, BackendDB or BackendHTTP [Default: BackendAuto]
+	Backend string
+
+	// Hosts lists the databases (host or host:port) to connect to; with
+	// several, every query is raced across them and the fastest one wins
+	Hosts []string
+
+	Port   int    // Port of hosts given without one [Default: 5432]
+	User   string // Database user [Default: guest]
+	DBName string // Database name [Default: certwatch]
+
+	SSLMode     string // libpq sslmode (disable, require, verify-ca, verify-full); empty uses the libpq default
+	SSLRootCert string // Path to the CA certificate used to verify the server
+	SSLCert     string // Path to the client certificate
+	SSLKey      string // Path to the client key
+
+	// QueryComment tags every query with a /* comment */ for server-side logs
+	QueryComment string
+
+	// MaxIdle discards pooled connections idle for longer than this, so the
+	// next query reconnects instead of failing on a server-side timeout
+	MaxIdle time.Duration
+
+	// Filter narrows down every query made through the client
+	Filter Filter
+
+	// Log receives the connection and query messages [Default: discarded]
+	Log *slog.Logger
+
+	// Output controls how Render formats results
+	Output result.Options
+}
+
+// Filter holds optional constraints on the certificates of every query
+type Filter struct {
+	MinCertID int64 // Only certificates with crt.sh ID >= MinCertID (0 = no bound)
+	MaxCertID int64 // Only certificates with crt.sh ID <= MaxCertID (0 = no bound)
+
+	Since time.Time // Only certificates first logged at or after Since (zero = no bound)
+	Until time.Time // Only certificates first logged before Until (zero = no bound)
+
+	ExpiringBy time.Time // Only unexpired certificates whose NotAfter is before ExpiringBy (zero = no bound)
+}
+
+// Options holds the settings of a single query
+type Options struct {
+	Expired bool // Exclude expired certificates
+	Limit   int  // Maximum number of results [Default: DefaultLimit]
+}
+
+func (o Options) limit() int {
+	if o.Limit <= 0 {
+		return DefaultLimit
+	}
+	return o.Limit
+}
+
+// Client queries crt.sh; it is safe for concurrent use
+type Client struct {
+	repo   *repository.Repository
+	output result.Options
+}
+
+// New connects to crt.sh (or the databases or backend of cfg)
+func New(cfg Config) (*Client, error) {
+	if cfg.Log == nil {
+		cfg.Log = slog.New(slog.DiscardHandler)
+	}
+
+	repo, err := repository.NewWithConfig(repository.Config{
+		Backend: cfg.Backend,
+
+		Hosts:  cfg.Hosts,
+		Port:   cfg.Port,
+		User:   cfg.User,
+		DBName: cfg.DBName,
+
+		SSLMode:     cfg.SSLMode,
+		SSLRootCert: cfg.SSLRootCert,
+		SSLCert:     cfg.SSLCert,
+		SSLKey:      cfg.SSLKey,
+
+		QueryComment: cfg.QueryComment,
+		MaxIdle:      cfg.MaxIdle,
+		Log:          cfg.Log,
+	})
+	if err != nil {
+		return nil, err
+	}
+	repo.Filter = repository.Filter(cfg.Filter)
+	return &Client{repo: repo, output: cfg.Output}, nil
+}
+
+// Lookup returns the certificates of domain, newest first
+func (c *Client) Lookup(ctx context.Context, domain string, opts Options) (result.Certificates, error) {
+	return c.repo.GetCertLogs(ctx, domain, opts.Expired, opts.limit())
+}
+
+// Subdomains returns the names under domain found in its certificates
+func (c *Client) Subdomains(ctx context.Context, domain string, opts Options) (result.Subdomains, error) {
+	return c.repo.GetSubdomains(ctx, domain, opts.Expired, opts.limit())
+}
+
+// Categorized returns the certificates of domain split into those of the
+// apex domain itself and those of its subdomains
+func (c *Client) Categorized(ctx context.Context, domain string, opts Options) (apex, subs result.Certificates, err error) {
+	return c.repo.GetCategorizedCertLogs(ctx, domain, opts.Expired, opts.limit())
+}
+
+// Search returns the certificates matching value, looked up as kind (one of
+// SearchSerial, SearchIssuer or SearchOrg)
+func (c *Client) Search(ctx context.Context, kind, value string, opts Options) (result.Certificates, error) {
+	switch kind {
+	case SearchSerial:
+		return c.repo.GetBySerial(ctx, value, opts.Expired, opts.limit())
+	case SearchIssuer:
+		return c.repo.GetByIssuer(ctx, value, opts.Expired, opts.limit())
+	case SearchOrg:
+		return c.repo.GetByOrg(ctx, value, opts.Expired, opts.limit())
+	}
+	return nil, fmt.Errorf("unknown search %q", kind)
+}
+
+// Render formats results (as returned by the client) as format, one of the
+// Format constants, with the Output options of the client
+func (c *Client) Render(p result.Printer, format string) ([]byte, error) {
+	switch format {
+	case FormatTable:
+		return c.output.Table(p), nil
+	case FormatMarkdown:
+		return c.output.Markdown(p), nil
+	case FormatHTML:
+		return c.output.HTML(p), nil
+	case FormatCSV:
+		return c.output.CSV(p)
+	case FormatTSV:
+		return c.output.TSV(p)
+	case FormatJSON:
+		return c.output.JSON(p)
+	case FormatYAML:
+		return c.output.YAML(p)
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// Close closes the database connections
+func (c *Client) Close() error {
+	return c.repo.Close()
+}
@@ -3,8 +3,23 @@ module github.com/pkgforge-security/crt
 go 1.24.1
 
 require (
+	github.com/atotto/clipboard v0.1.4
 	github.com/lib/pq v1.10.9
 	github.com/olekukonko/tablewriter v0.0.5
+	golang.org/x/time v0.9.0
+	gopkg.in/yaml.v3 v3.0.1
+	modernc.org/sqlite v1.34.5
 )
 
-require github.com/mattn/go-runewidth v0.0.9 // indirect
+require (
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/mattn/go-runewidth v0.0.9 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	golang.org/x/sys v0.29.0 // indirect
+	modernc.org/libc v1.55.3 // indirect
+	modernc.org/mathutil v1.6.0 // indirect
+	modernc.org/memory v1.8.0 // indirect
+)
@@ -5,9 +5,11 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"math/rand"
-	"os"
+	"log/slog"
+	"net"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkgforge-security/crt/result"
@@ -20,7 +22,6 @@ var (
 	port    = 5432
 	user    = "guest"
 	dbname  = "certwatch"
-	login   = fmt.Sprintf("host=%s port=%d user=%s dbname=%s", host, port, user, dbname)
 
 	maxRetries   = 3
 	initialDelay = 2 * time.Second
@@ -28,18 +29,196 @@ var (
 )
 
 type Repository struct {
-	db *sql.DB
+	// One pool per database host; queries race across all of them
+	dbs []*sql.DB
+	mu  sync.RWMutex // Guards dbs against reconnect
+
+	// Settings (and log) of the repository and host of each pool, to reconnect it
+	cfg   Config
+	addrs []string
+
+	// Serializes reconnects, so concurrent queries don't all reconnect a host
+	reconnectMu sync.Mutex
+
+	// Set when queries go to the HTTP API instead of the database
+	api *apiClient
+
+	// SQL comment prefixed to every query (see Config.QueryComment)
+	comment string
+
+	// Filter narrows down every query made through the repository
+	Filter Filter
+}
+
+// Filter holds optional constraints added to the WHERE clause of queries
+type Filter struct {
+	MinCertID int64 // Only certificates with crt.sh ID >= MinCertID (0 = no bound)
+	MaxCertID int64 // Only certificates with crt.sh ID <= MaxCertID (0 = no bound)
+
+	Since time.Time // Only certificates first logged at or after Since (zero = no bound)
+	Until time.Time // Only certificates first logged before Until (zero = no bound)
+
+	ExpiringBy time.Time // Only unexpired certificates whose NotAfter is before ExpiringBy (zero = no bound)
+}
+
+// Config holds optional connection settings; the zero value connects to crt.sh
+type Config struct {
+	// Backend is one of BackendAuto, BackendDB or BackendHTTP [Default: BackendAuto]
+	Backend string
+
+	// Hosts lists the databases (host or host:port) to connect to; with
+	// several, every query is raced across them and the fastest one wins
+	Hosts []string
+
+	Port   int    // Port of hosts given without one [Default: 5432]
+	User   string // Database user [Default: guest]
+	DBName string // Database name [Default: certwatch]
+
+	SSLMode     string // libpq sslmode (disable, require, verify-ca, verify-full); empty uses the libpq default
+	SSLRootCert string // Path to the CA certificate used to verify the server
+	SSLCert     string // Path to the client certificate
+	SSLKey      string // Path to the client key
+
+	// QueryComment tags every query with a /* comment */ for server-side logs
+	QueryComment string
+
+	// MaxIdle discards pooled connections idle for longer than this, so the
+	// next query reconnects instead of failing on a server-side timeout
+	MaxIdle time.Duration
+
+	// Log receives the connection and query messages (nil = discarded)
+	Log *slog.Logger
+}
+
+// hosts returns the configured database hosts, defaulting to crt.sh
+func (c Config) hosts() []string {
+	if len(c.Hosts) == 0 {
+		return []string{host}
+	}
+	return c.Hosts
+}
+
+// dsn builds the connection string of a host for the configured settings
+func (c Config) dsn(addr string) string {
+	h, p := addr, strconv.Itoa(port)
+	if c.Port > 0 {
+		p = strconv.Itoa(c.Port)
+	}
+	if bh, bp, err := net.SplitHostPort(addr); err == nil {
+		h, p = bh, bp
+	}
+
+	u, name := user, dbname
+	if c.User != "" {
+		u = c.User
+	}
+	if c.DBName != "" {
+		name = c.DBName
+	}
+
+	dsn := fmt.Sprintf("host=%s port=%s user=%s dbname=%s", quoteDSN(h), p, quoteDSN(u), quoteDSN(name))
+	if c.SSLMode != "" {
+		dsn += " sslmode=" + c.SSLMode
+	}
+	if c.SSLRootCert != "" {
+		dsn += " sslrootcert=" + quoteDSN(c.SSLRootCert)
+	}
+	if c.SSLCert != "" {
+		dsn += " sslcert=" + quoteDSN(c.SSLCert)
+	}
+	if c.SSLKey != "" {
+		dsn += " sslkey=" + quoteDSN(c.SSLKey)
+	}
+	return dsn
+}
+
+// quoteDSN quotes a connection string value, so paths may contain spaces
+func quoteDSN(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
+}
+
+// sqlComment turns text into a /* comment */ line, keeping only characters
+// that can't end the comment early or otherwise alter the query
+func sqlComment(text string) string {
+	var b strings.Builder
+	for _, c := range text {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			b.WriteRune(c)
+		case strings.ContainsRune(" _-.,:=@+#", c):
+			b.WriteRune(c)
+		}
+	}
+
+	comment := strings.TrimSpace(b.String())
+	if comment == "" {
+		return ""
+	}
+	return "/* " + comment + " */\n"
 }
 
-// logf prints messages only if quiet mode is disabled
-func logf(format string, args ...interface{}) {
-	fmt.Fprintf(os.Stderr, format, args...)
+// log returns Log, or a logger discarding everything if it isn't set
+func (c Config) log() *slog.Logger {
+	if c.Log == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return c.Log
 }
 
 func New() (*Repository, error) {
+	return NewWithConfig(Config{})
+}
+
+// NewWithConfig connects like New, using the given connection settings.
+// Hosts that can't be reached are skipped, as long as one connects; if none
+// does, BackendAuto falls back to the HTTP API.
+func NewWithConfig(cfg Config) (*Repository, error) {
+	switch cfg.Backend {
+	case BackendHTTP:
+		cfg.log().Info("Using the crt.sh JSON API", "url", apiURL)
+		return &Repository{api: newAPIClient(), cfg: cfg}, nil
+	case "", BackendAuto:
+		r, err := newDBRepository(cfg)
+		if err != nil {
+			cfg.log().Warn("Database unavailable, falling back to the crt.sh JSON API", "url", apiURL)
+			return &Repository{api: newAPIClient(), cfg: cfg}, nil
+		}
+		return r, nil
+	case BackendDB:
+		return newDBRepository(cfg)
+	}
+	return nil, fmt.Errorf("Unknown backend %q (available: %s, %s, %s)", cfg.Backend, BackendAuto, BackendDB, BackendHTTP)
+}
+
+// newDBRepository connects to every configured database host
+func newDBRepository(cfg Config) (*Repository, error) {
+	r := &Repository{comment: sqlComment(cfg.QueryComment), cfg: cfg}
+
+	var lastErr error
+	for _, addr := range cfg.hosts() {
+		db, err := connect(cfg, addr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		r.dbs = append(r.dbs, db)
+		r.addrs = append(r.addrs, addr)
+	}
+
+	if len(r.dbs) == 0 {
+		return nil, lastErr
+	}
+	return r, nil
+}
+
+// connect opens and verifies the connection pool of a single host
+func connect(cfg Config, addr string) (*sql.DB, error) {
 	startTime := time.Now()
+	dsn := cfg.dsn(addr)
 
-	db, err := sql.Open(driver, login+" connect_timeout=20")
+	db, err := sql.Open(driver, dsn+" connect_timeout=20")
 	if err != nil {
 		return nil, fmt.Errorf("Failed to Initialize DB Connection: %w", err)
 	}
@@ -47,6 +226,9 @@ func New() (*Repository, error) {
 	db.SetConnMaxLifetime(5 * time.Minute)
 	db.SetMaxOpenConns(20)
 	db.SetMaxIdleConns(10)
+	if cfg.MaxIdle > 0 {
+		db.SetConnMaxIdleTime(cfg.MaxIdle)
+	}
 
 	var lastErr error
 	delay := initialDelay
@@ -57,15 +239,15 @@ func New() (*Repository, error) {
 		cancel()
 
 		if lastErr == nil {
-			logf("📡 Connected ==> [%s] (%v)\n", login, time.Since(startTime))
-			return &Repository{db}, nil
+			cfg.log().Info("Connected", "host", addr, "took", time.Since(startTime).Round(time.Millisecond))
+			return db, nil
 		}
 
-		logf("⚠️ Connection attempt %d Failed: %v\n", retries+1, lastErr)
+		cfg.log().Warn("Connection attempt failed", "host", addr, "attempt", retries+1, "error", lastErr)
 
 		if retries < maxRetries-1 {
 			// Add jitter (randomized wait time to avoid synchronized retries)
-			jitter := time.Duration(rand.Int63n(int64(delay / 2)))
+			jitter := time.Duration(Rand.Int63n(int64(delay / 2)))
 			sleepTime := delay + jitter
 			time.Sleep(sleepTime)
 
@@ -75,7 +257,7 @@ func New() (*Repository, error) {
 	}
 
 	db.Close()
-	logf("❌ Connection Failed after %v\n", time.Since(startTime))
+	cfg.log().Error("Connection failed", "host", addr, "took", time.Since(startTime).Round(time.Millisecond))
 	return nil, fmt.Errorf("Failed to connect to database after %d attempts: %w", maxRetries, lastErr)
 }
 
@@ -87,32 +269,128 @@ func min(a, b time.Duration) time.Duration {
 	return b
 }
 
-// sanitizeDomain ensures the domain is safe for SQL queries by escaping `%`
-func sanitizeDomain(domain string) string {
-	return strings.ReplaceAll(domain, "%", "\\%")
+// likePattern escapes the LIKE wildcards in domain; query parameters take
+// care of quoting, but ILIKE would still treat % and _ as wildcards
+func likePattern(domain string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(domain)
 }
 
-func (r *Repository) GetCertLogs(domain string, expired bool, limit int) (result.Certificates, error) {
-	startTime := time.Now()
+// query runs stmt on every database host at once and returns the rows of the first
+// one to succeed, cancelling the others. done must be called once the rows
+// are closed. Cancelling ctx aborts the query on the server.
+func (r *Repository) query(ctx context.Context, stmt string, args ...interface{}) (rows *sql.Rows, done func(), err error) {
+	if len(r.dbs) == 1 {
+		rows, err = r.queryHost(ctx, 0, stmt, args...)
+		return rows, func() {}, err
+	}
 
-	if r.db == nil {
-		return nil, errors.New("Database Connection is nil")
+	type response struct {
+		host int
+		rows *sql.Rows
+		err  error
+	}
+
+	responses := make(chan response, len(r.dbs))
+	cancels := make([]context.CancelFunc, len(r.dbs))
+	for i := range r.dbs {
+		hostCtx, cancel := context.WithCancel(ctx)
+		cancels[i] = cancel
+		go func() {
+			rows, err := r.queryHost(hostCtx, i, stmt, args...)
+			responses <- response{i, rows, err}
+		}()
+	}
+
+	for received := 1; received <= len(r.dbs); received++ {
+		res := <-responses
+		if res.err != nil {
+			cancels[res.host]()
+			err = res.err
+			continue
+		}
+
+		// Cancel the slower hosts and release whatever they still return
+		for i, cancel := range cancels {
+			if i != res.host {
+				cancel()
+			}
+		}
+		go func(pending int) {
+			for ; pending > 0; pending-- {
+				if late := <-responses; late.err == nil {
+					late.rows.Close()
+				}
+			}
+		}(len(r.dbs) - received)
+		return res.rows, cancels[res.host], nil
+	}
+	return nil, func() {}, err
+}
+
+// whereFilter builds the extra WHERE conditions for a query, with their values
+// appended to args as query parameters
+func (r *Repository) whereFilter(expired bool, args []interface{}) (string, []interface{}) {
+	var filters []string
+	add := func(filter string, value interface{}) {
+		args = append(args, value)
+		filters = append(filters, fmt.Sprintf(filter, len(args)))
 	}
 
-	domain = sanitizeDomain(domain)
-	filter := ""
 	if expired {
-		filter = excludeExpiredFilter
+		filters = append(filters, excludeExpiredFilter)
+	}
+	if r.Filter.MinCertID > 0 {
+		add(minCertIDFilter, r.Filter.MinCertID)
+	}
+	if r.Filter.MaxCertID > 0 {
+		add(maxCertIDFilter, r.Filter.MaxCertID)
+	}
+	if !r.Filter.Since.IsZero() {
+		add(sinceFilter, r.Filter.Since.UTC())
+	}
+	if !r.Filter.Until.IsZero() {
+		add(untilFilter, r.Filter.Until.UTC())
+	}
+	if !r.Filter.ExpiringBy.IsZero() {
+		add(expiringFilter, r.Filter.ExpiringBy.UTC())
+	}
+	return strings.Join(filters, "\n\t"), args
+}
+
+// GetCertLogs returns the certificates of domain, newest first; cancelling
+// ctx aborts the query
+func (r *Repository) GetCertLogs(ctx context.Context, domain string, expired bool, limit int) (result.Certificates, error) {
+	if r.api != nil {
+		return r.apiCertLogs(ctx, domain, expired, limit)
 	}
 
-	stmt := fmt.Sprintf(certLogScript, domain, domain, filter, limit)
+	startTime := time.Now()
 
-	rows, err := r.db.Query(stmt)
+	if len(r.dbs) == 0 {
+		return nil, errors.New("Database Connection is nil")
+	}
+
+	filter, args := r.whereFilter(expired, []interface{}{domain, likePattern(domain), limit})
+	stmt := r.comment + fmt.Sprintf(certLogScript, filter)
+
+	rows, done, err := r.query(ctx, stmt, args...)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to query db: %w", err)
 	}
+	defer done()
 	defer rows.Close()
 
+	res, err := scanCertificates(rows)
+	if err != nil {
+		return nil, err
+	}
+	r.cfg.log().Debug("Queried GetCertLogs", "domain", domain, "took", time.Since(startTime).Round(time.Millisecond))
+	return res, nil
+}
+
+// scanCertificates reads the rows of a certificate query (the columns of
+// certLogScript), treating NULLs as zero values
+func scanCertificates(rows *sql.Rows) (result.Certificates, error) {
 	var res result.Certificates
 
 	for rows.Next() {
@@ -122,7 +400,7 @@ func (r *Repository) GetCertLogs(domain string, expired bool, limit int) (result
 		var issuerName, commonName, nameValue, serialNumber sql.NullString
 		var entryTimestamp, notBefore, notAfter sql.NullTime
 
-		err = rows.Scan(
+		err := rows.Scan(
 			&issuerCaID,
 			&issuerName,
 			&commonName,
@@ -184,29 +462,43 @@ func (r *Repository) GetCertLogs(domain string, expired bool, limit int) (result
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("Error iterating over rows: %w", err)
 	}
-	logf("⏳ Query GetCertLogs ==> %s (%v)\n", domain, time.Since(startTime))
+	res.FlagImplausibleDates()
 	return res, nil
 }
 
-func (r *Repository) GetSubdomains(domain string, expired bool, limit int) (result.Subdomains, error) {
-	startTime := time.Now()
+// GetCategorizedCertLogs returns the certificates of domain split into those
+// covering the apex domain itself and those covering only its subdomains
+func (r *Repository) GetCategorizedCertLogs(ctx context.Context, domain string, expired bool, limit int) (result.Certificates, result.Certificates, error) {
+	res, err := r.GetCertLogs(ctx, domain, expired, limit)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	if r.db == nil {
-		return nil, errors.New("Database connection is nil")
+	apex, subdomains := res.Categorize(domain)
+	return apex, subdomains, nil
+}
+
+// GetSubdomains returns the names of domain's certificates; cancelling ctx
+// aborts the query
+func (r *Repository) GetSubdomains(ctx context.Context, domain string, expired bool, limit int) (result.Subdomains, error) {
+	if r.api != nil {
+		return r.apiSubdomains(ctx, domain, expired, limit)
 	}
 
-	domain = sanitizeDomain(domain)
-	filter := ""
-	if expired {
-		filter = excludeExpiredFilter
+	startTime := time.Now()
+
+	if len(r.dbs) == 0 {
+		return nil, errors.New("Database connection is nil")
 	}
 
-	stmt := fmt.Sprintf(subdomainScript, domain, domain, filter, limit)
+	filter, args := r.whereFilter(expired, []interface{}{domain, likePattern(domain), limit})
+	stmt := r.comment + fmt.Sprintf(subdomainScript, filter)
 
-	rows, err := r.db.Query(stmt)
+	rows, done, err := r.query(ctx, stmt, args...)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to query row: %w", err)
 	}
+	defer done()
 	defer rows.Close()
 
 	var res result.Subdomains
@@ -227,14 +519,30 @@ func (r *Repository) GetSubdomains(domain string, expired bool, limit int) (resu
 		return nil, fmt.Errorf("Error iterating over rows: %w", err)
 	}
 
-	logf("⏳ Query GetSubdomains ==> %s (%v)\n", domain, time.Since(startTime))
+	r.cfg.log().Debug("Queried GetSubdomains", "domain", domain, "took", time.Since(startTime).Round(time.Millisecond))
 
+	// DISTINCT is case-sensitive, so repeats may still differ in case
+	res = res.Dedupe()
+	res.MarkWildcards()
 	return res, nil
 }
 
 func (r *Repository) Close() error {
-	if r.db == nil {
+	if r.api != nil {
+		return nil
+	}
+	if len(r.dbs) == 0 {
 		return errors.New("Database connection is already closed or nil")
 	}
-	return r.db.Close()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var err error
+	for _, db := range r.dbs {
+		if cerr := db.Close(); cerr != nil {
+			err = cerr
+		}
+	}
+	return err
 }
\ No newline at end of file
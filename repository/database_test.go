@@ -0,0 +1,352 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkgforge-security/crt/result"
+)
+
+func TestWhereFilterCertID(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  Filter
+		where   []string
+		args    []interface{}
+		expired bool
+	}{
+		{"none", Filter{}, nil, []interface{}{}, false},
+		{"min", Filter{MinCertID: 100}, []string{"AND cai.CERTIFICATE_ID >= $4"}, []interface{}{int64(100)}, false},
+		{"max", Filter{MaxCertID: 200}, []string{"AND cai.CERTIFICATE_ID <= $4"}, []interface{}{int64(200)}, false},
+		{"range", Filter{MinCertID: 100, MaxCertID: 200}, []string{"AND cai.CERTIFICATE_ID >= $4", "AND cai.CERTIFICATE_ID <= $5"}, []interface{}{int64(100), int64(200)}, false},
+		{"range and expired", Filter{MinCertID: 100, MaxCertID: 200}, []string{excludeExpiredFilter, "AND cai.CERTIFICATE_ID >= $4", "AND cai.CERTIFICATE_ID <= $5"}, []interface{}{int64(100), int64(200)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Repository{Filter: tt.filter}
+			base := []interface{}{"example.com", "%.example.com", 10}
+
+			where, args := r.whereFilter(tt.expired, base)
+			if want := strings.Join(tt.where, "\n\t"); where != want {
+				t.Errorf("filter = %q, want %q", where, want)
+			}
+			if !reflect.DeepEqual(args[len(base):], tt.args) {
+				t.Errorf("args = %v, want %v", args[len(base):], tt.args)
+			}
+		})
+	}
+}
+
+func TestFilterKeepCertID(t *testing.T) {
+	filter := Filter{MinCertID: 100, MaxCertID: 200}
+	tests := []struct {
+		id   int
+		want bool
+	}{
+		{99, false},
+		{100, true},
+		{150, true},
+		{200, true},
+		{201, false},
+	}
+
+	for _, tt := range tests {
+		if got := filter.keep(result.Certificate{ID: tt.id}); got != tt.want {
+			t.Errorf("keep(ID %d) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestConfigDSN(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		addr string
+		want string
+	}{
+		{"defaults", Config{}, "crt.sh",
+			"host='crt.sh' port=5432 user='guest' dbname='certwatch'"},
+		{"sslmode", Config{SSLMode: "verify-full"}, "crt.sh",
+			"host='crt.sh' port=5432 user='guest' dbname='certwatch' sslmode=verify-full"},
+		{"tls files", Config{SSLMode: "verify-ca", SSLRootCert: "/etc/ssl/my ca.pem", SSLCert: "client.crt", SSLKey: "client.key"}, "db.local:6432",
+			"host='db.local' port=6432 user='guest' dbname='certwatch' sslmode=verify-ca sslrootcert='/etc/ssl/my ca.pem' sslcert='client.crt' sslkey='client.key'"},
+		{"quoted values", Config{User: `o'brien`, Port: 15432}, "db.local",
+			`host='db.local' port=15432 user='o\'brien' dbname='certwatch'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.dsn(tt.addr); got != tt.want {
+				t.Errorf("dsn(%q) =\n%s\nwant\n%s", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLComment(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"empty", "", ""},
+		{"plain", "scan-42 by team@example.com", "/* scan-42 by team@example.com */\n"},
+		{"closes the comment", "x */ DROP TABLE ca; /*", "/* x  DROP TABLE ca */\n"},
+		{"line comment and quotes", "a'--b\"", "/* a--b */\n"},
+		{"newlines", "job\n; SELECT 1", "/* job SELECT 1 */\n"},
+		{"nothing left", "*/;'", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sqlComment(tt.text)
+			if got != tt.want {
+				t.Errorf("sqlComment(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+			if body := strings.TrimSuffix(strings.TrimPrefix(got, "/* "), " */\n"); strings.Contains(body, "*/") || strings.Contains(body, "/*") {
+				t.Errorf("sqlComment(%q) = %q can end the comment early", tt.text, got)
+			}
+		})
+	}
+}
+
+func init() {
+	sql.Register("crt-record", recordDriver{})
+}
+
+// recordDriver is a database that records the statements it runs and their
+// arguments, and returns no rows
+type recordDriver struct{}
+
+// recorded holds the statement and arguments of the last query
+var recorded struct {
+	sync.Mutex
+	stmt string
+	args []interface{}
+}
+
+func (recordDriver) Open(string) (sqldriver.Conn, error) { return recordConn{}, nil }
+
+type recordConn struct{}
+
+func (recordConn) QueryContext(_ context.Context, stmt string, args []sqldriver.NamedValue) (sqldriver.Rows, error) {
+	recorded.Lock()
+	defer recorded.Unlock()
+	recorded.stmt, recorded.args = stmt, nil
+	for _, arg := range args {
+		recorded.args = append(recorded.args, arg.Value)
+	}
+	return idleRows{}, nil
+}
+
+func (recordConn) Prepare(string) (sqldriver.Stmt, error) { return nil, errors.New("not supported") }
+func (recordConn) Close() error                           { return nil }
+func (recordConn) Begin() (sqldriver.Tx, error)           { return nil, errors.New("not supported") }
+
+func TestLikePattern(t *testing.T) {
+	tests := []struct {
+		domain string
+		want   string
+	}{
+		{"example.com", "example.com"},
+		{"%", `\%`},
+		{"my_host.example.com", `my\_host.example.com`},
+		{`a\%b`, `a\\\%b`},
+		{"o'reilly.com", "o'reilly.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.domain, func(t *testing.T) {
+			if got := likePattern(tt.domain); got != tt.want {
+				t.Errorf("likePattern(%q) = %q, want %q", tt.domain, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdversarialDomains(t *testing.T) {
+	db, err := sql.Open("crt-record", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	r := &Repository{dbs: []*sql.DB{db}, addrs: []string{"record"}}
+
+	domains := []string{
+		"example.com' OR '1'='1",
+		"example.com'; DROP TABLE certificate; --",
+		`example.com\'); SELECT pg_sleep(10); --`,
+		"%",
+		"_xample.com",
+		"example.com /* comment */",
+		"$1",
+	}
+
+	queries := []struct {
+		name string
+		run  func(domain string) error
+	}{
+		{"certificates", func(domain string) error {
+			_, err := r.GetCertLogs(context.Background(), domain, false, 10)
+			return err
+		}},
+		{"subdomains", func(domain string) error {
+			_, err := r.GetSubdomains(context.Background(), domain, true, 10)
+			return err
+		}},
+	}
+
+	for _, query := range queries {
+		if err := query.run("example.com"); err != nil {
+			t.Fatal(err)
+		}
+		recorded.Lock()
+		stmt := recorded.stmt
+		recorded.Unlock()
+
+		for _, domain := range domains {
+			t.Run(query.name+"/"+domain, func(t *testing.T) {
+				if err := query.run(domain); err != nil {
+					t.Fatal(err)
+				}
+
+				recorded.Lock()
+				defer recorded.Unlock()
+				// The domain only ever reaches the database as a parameter
+				if recorded.stmt != stmt {
+					t.Errorf("statement for %q differs from the one for example.com:\n%s", domain, recorded.stmt)
+				}
+				if want := []interface{}{domain, likePattern(domain), int64(10)}; !reflect.DeepEqual(recorded.args, want) {
+					t.Errorf("args = %#v, want %#v", recorded.args, want)
+				}
+			})
+		}
+	}
+}
+
+func init() {
+	sql.Register("crt-stub", stubDriver{})
+}
+
+// stubDriver is a database named by its DSN: "slow" answers after
+// stubSlowDelay unless the query is cancelled, "down" fails and any other
+// answers at once. Each returns its own name as the only row.
+type stubDriver struct{}
+
+const stubSlowDelay = 300 * time.Millisecond
+
+// stubSlowAnswered counts the queries the slow backend answered
+var stubSlowAnswered atomic.Int32
+
+func (stubDriver) Open(name string) (sqldriver.Conn, error) { return stubConn(name), nil }
+
+type stubConn string
+
+func (c stubConn) QueryContext(ctx context.Context, _ string, _ []sqldriver.NamedValue) (sqldriver.Rows, error) {
+	switch c {
+	case "slow":
+		select {
+		case <-time.After(stubSlowDelay):
+			stubSlowAnswered.Add(1)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	case "down":
+		return nil, errors.New("connection refused")
+	}
+	return &stubRows{name: string(c)}, nil
+}
+
+func (stubConn) Prepare(string) (sqldriver.Stmt, error) { return nil, errors.New("not supported") }
+func (stubConn) Close() error                           { return nil }
+func (stubConn) Begin() (sqldriver.Tx, error)           { return nil, errors.New("not supported") }
+
+type stubRows struct {
+	name string
+	done bool
+}
+
+func (r *stubRows) Columns() []string { return []string{"name"} }
+func (r *stubRows) Close() error      { return nil }
+func (r *stubRows) Next(dest []sqldriver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.name
+	return nil
+}
+
+func TestQueryRace(t *testing.T) {
+	tests := []struct {
+		name     string
+		backends []string
+		want     string // Backend whose rows are returned; "" for an error
+	}{
+		{"fast first", []string{"fast", "slow"}, "fast"},
+		{"slow first", []string{"slow", "fast"}, "fast"},
+		{"one down", []string{"down", "fast"}, "fast"},
+		{"slow and down", []string{"down", "slow"}, "slow"},
+		{"all down", []string{"down", "down"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Repository{addrs: tt.backends}
+			for _, backend := range tt.backends {
+				db, err := sql.Open("crt-stub", backend)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer db.Close()
+				r.dbs = append(r.dbs, db)
+			}
+			stubSlowAnswered.Store(0)
+
+			start := time.Now()
+			rows, done, err := r.query(context.Background(), "SELECT name")
+			if tt.want == "" {
+				if err == nil {
+					rows.Close()
+					t.Fatal("want an error when every backend is down")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got string
+			for rows.Next() {
+				rows.Scan(&got)
+			}
+			rows.Close()
+			done()
+
+			if got != tt.want {
+				t.Errorf("rows of %q, want %q", got, tt.want)
+			}
+			if tt.want != "fast" {
+				return
+			}
+			if took := time.Since(start); took >= stubSlowDelay {
+				t.Errorf("took %s, waited for the slow backend", took)
+			}
+
+			// The slow query is cancelled rather than left running
+			time.Sleep(2 * stubSlowDelay)
+			if n := stubSlowAnswered.Load(); n != 0 {
+				t.Errorf("the slow backend answered %d queries after losing the race", n)
+			}
+		})
+	}
+}
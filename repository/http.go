@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkgforge-security/crt/result"
+)
+
+// Backends selectable with Config.Backend
+const (
+	BackendAuto = "auto" // The database, falling back to the HTTP API if it can't be reached
+	BackendDB   = "db"   // Only the Postgres database
+	BackendHTTP = "http" // Only the crt.sh JSON API (https://crt.sh/?q=...&output=json)
+)
+
+// apiURL is the crt.sh endpoint queried by the HTTP backend
+var apiURL = "https://crt.sh/"
+
+// apiClient queries the crt.sh JSON API, for when the database is down
+type apiClient struct {
+	client *http.Client
+}
+
+func newAPIClient() *apiClient {
+	return &apiClient{client: &http.Client{Timeout: 2 * time.Minute}}
+}
+
+// apiCertificate is a record of the crt.sh JSON API; its timestamps have no
+// time zone and are in UTC
+type apiCertificate struct {
+	IssuerCaID     int    `json:"issuer_ca_id"`
+	IssuerName     string `json:"issuer_name"`
+	CommonName     string `json:"common_name"`
+	NameValue      string `json:"name_value"`
+	ID             int    `json:"id"`
+	EntryTimestamp string `json:"entry_timestamp"`
+	NotBefore      string `json:"not_before"`
+	NotAfter       string `json:"not_after"`
+	SerialNumber   string `json:"serial_number"`
+}
+
+// parseAPITime parses an API timestamp, leaving missing or invalid ones zero
+func parseAPITime(s string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02T15:04:05.999999999", s, time.UTC)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// certificates fetches every certificate of domain, newest first
+func (a *apiClient) certificates(ctx context.Context, domain string, expired bool) (result.Certificates, error) {
+	return a.search(ctx, url.Values{"q": {domain}}, expired)
+}
+
+// search fetches every certificate matching the crt.sh search params (e.g.
+// q=example.com or serial=...), newest first
+func (a *apiClient) search(ctx context.Context, params url.Values, expired bool) (result.Certificates, error) {
+	params.Set("output", "json")
+	if expired {
+		params.Set("exclude", "expired")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to query API: %w", err)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to query API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Failed to query API: %s", resp.Status)
+	}
+
+	var records []apiCertificate
+	if err := json.NewDecoder(resp.Body).Decode(&records); err != nil {
+		return nil, fmt.Errorf("Failed to decode API response: %w", err)
+	}
+
+	// Records may repeat a certificate once per identity, like the database
+	index := make(map[int]int, len(records))
+	var res result.Certificates
+	for _, rec := range records {
+		if i, ok := index[rec.ID]; ok {
+			res[i].NameValue = mergeNames(res[i].NameValue, rec.NameValue)
+			continue
+		}
+		index[rec.ID] = len(res)
+		res = append(res, result.Certificate{
+			IssuerCaID:     rec.IssuerCaID,
+			IssuerName:     rec.IssuerName,
+			CommonName:     rec.CommonName,
+			NameValue:      mergeNames("", rec.NameValue),
+			ID:             rec.ID,
+			EntryTimestamp: parseAPITime(rec.EntryTimestamp),
+			NotBefore:      parseAPITime(rec.NotBefore),
+			NotAfter:       parseAPITime(rec.NotAfter),
+			SerialNumber:   rec.SerialNumber,
+		})
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].EntryTimestamp.After(res[j].EntryTimestamp)
+	})
+	return res, nil
+}
+
+// mergeNames adds the newline-separated names of add to names, sorted and
+// without duplicates (like the database's array_agg(DISTINCT ...))
+func mergeNames(names, add string) string {
+	set := make(map[string]bool)
+	for _, name := range strings.Split(names+"\n"+add, "\n") {
+		if name = strings.TrimSpace(name); name != "" {
+			set[name] = true
+		}
+	}
+
+	merged := make([]string, 0, len(set))
+	for name := range set {
+		merged = append(merged, name)
+	}
+	sort.Strings(merged)
+	return strings.Join(merged, "\n")
+}
+
+// keep reports whether the certificate passes the filter, which the API
+// can't apply itself
+func (f Filter) keep(cert result.Certificate) bool {
+	if f.MinCertID > 0 && int64(cert.ID) < f.MinCertID {
+		return false
+	}
+	if f.MaxCertID > 0 && int64(cert.ID) > f.MaxCertID {
+		return false
+	}
+	if !f.Since.IsZero() && cert.EntryTimestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !cert.EntryTimestamp.Before(f.Until) {
+		return false
+	}
+	if !f.ExpiringBy.IsZero() && (cert.NotAfter.Before(time.Now()) || !cert.NotAfter.Before(f.ExpiringBy)) {
+		return false
+	}
+	return true
+}
+
+func (r *Repository) apiCertLogs(ctx context.Context, domain string, expired bool, limit int) (result.Certificates, error) {
+	startTime := time.Now()
+
+	certs, err := r.api.certificates(ctx, domain, expired)
+	if err != nil {
+		return nil, err
+	}
+
+	var res result.Certificates
+	for _, cert := range certs {
+		if len(res) == limit {
+			break
+		}
+		if r.Filter.keep(cert) {
+			res = append(res, cert)
+		}
+	}
+
+	res.FlagImplausibleDates()
+	r.cfg.log().Debug("Queried GetCertLogs (HTTP)", "domain", domain, "took", time.Since(startTime).Round(time.Millisecond))
+	return res, nil
+}
+
+func (r *Repository) apiSubdomains(ctx context.Context, domain string, expired bool, limit int) (result.Subdomains, error) {
+	startTime := time.Now()
+
+	certs, err := r.api.certificates(ctx, domain, expired)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var res result.Subdomains
+	for _, cert := range certs {
+		if !r.Filter.keep(cert) {
+			continue
+		}
+		for _, name := range strings.Split(cert.NameValue, "\n") {
+			if len(res) == limit {
+				break
+			}
+			// Same match as the database's ILIKE '%domain%'
+			if seen[name] || !strings.Contains(strings.ToLower(name), strings.ToLower(domain)) {
+				continue
+			}
+			seen[name] = true
+			res = append(res, result.Subdomain{Name: name})
+		}
+	}
+
+	r.cfg.log().Debug("Queried GetSubdomains (HTTP)", "domain", domain, "took", time.Since(startTime).Round(time.Millisecond))
+	res = res.Dedupe()
+	res.MarkWildcards()
+	return res, nil
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Rand is the shared source of randomness (connection and retry jitter),
+// seeded from the clock unless Seed is called
+var Rand = rand.New(&lockedSource{src: rand.NewSource(time.Now().UnixNano()).(rand.Source64)})
+
+// Seed re-seeds Rand, making randomized behavior reproducible
+func Seed(seed int64) {
+	Rand.Seed(seed)
+}
+
+// lockedSource makes a rand.Source safe for concurrent use
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source64
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
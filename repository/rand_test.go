@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSeed(t *testing.T) {
+	draw := func(seed int64) []int64 {
+		Seed(seed)
+		res := make([]int64, 10)
+		for i := range res {
+			res[i] = Rand.Int63n(1000)
+		}
+		return res
+	}
+
+	tests := []struct {
+		name string
+		a, b int64
+		same bool
+	}{
+		{"same seed", 42, 42, true},
+		{"other seed", 42, 43, false},
+		{"zero seed", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := draw(tt.a), draw(tt.b)
+			if reflect.DeepEqual(a, b) != tt.same {
+				t.Errorf("seeds %d and %d drew %v and %v", tt.a, tt.b, a, b)
+			}
+		})
+	}
+}
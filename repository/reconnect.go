@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// host returns the connection pool of the i-th database host
+func (r *Repository) host(i int) *sql.DB {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.dbs[i]
+}
+
+// queryHost runs stmt on the i-th database host. If it fails while ctx is
+// still live and the host no longer answers a ping (e.g. crt.sh dropped the
+// connection), the host is reconnected and stmt runs once more.
+func (r *Repository) queryHost(ctx context.Context, i int, stmt string, args ...interface{}) (*sql.Rows, error) {
+	db := r.host(i)
+	rows, err := db.QueryContext(ctx, stmt, args...)
+	if err == nil || ctx.Err() != nil {
+		return rows, err
+	}
+
+	if !r.reconnect(i, db) {
+		return nil, err
+	}
+	return r.host(i).QueryContext(ctx, stmt, args...)
+}
+
+// reconnect replaces db, the pool of the i-th host, with a new connection
+// (retried like New does) if it is dead. It reports whether the host has a
+// new pool, made by this or by a concurrent call.
+func (r *Repository) reconnect(i int, db *sql.DB) bool {
+	r.reconnectMu.Lock()
+	defer r.reconnectMu.Unlock()
+
+	// Another query reconnected while this one waited
+	if r.host(i) != db {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	err := db.PingContext(ctx)
+	cancel()
+	if err == nil {
+		// The connection is fine, the query itself failed
+		return false
+	}
+
+	r.cfg.log().Warn("Lost connection, reconnecting", "host", r.addrs[i], "error", err)
+	fresh, err := connect(r.cfg, r.addrs[i])
+	if err != nil {
+		return false
+	}
+
+	r.mu.Lock()
+	r.dbs[i] = fresh
+	r.mu.Unlock()
+
+	// Close waits for the queries still running on the old pool
+	db.Close()
+	return true
+}
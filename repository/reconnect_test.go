@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// serverIdleTimeout is how long the fake server keeps an idle connection
+const serverIdleTimeout = 300 * time.Millisecond
+
+func init() {
+	sql.Register("crt-idle", idleDriver{})
+}
+
+// idleDriver is a database whose server drops connections idle for longer
+// than serverIdleTimeout, like crt.sh does
+type idleDriver struct{}
+
+// idleFailures counts the queries that failed on a dropped connection
+var idleFailures atomic.Int32
+
+func (idleDriver) Open(string) (sqldriver.Conn, error) {
+	return &idleConn{used: time.Now()}, nil
+}
+
+type idleConn struct{ used time.Time }
+
+// use fails if the server has dropped the connection meanwhile
+func (c *idleConn) use() error {
+	if time.Since(c.used) > serverIdleTimeout {
+		return errors.New("connection reset by peer")
+	}
+	c.used = time.Now()
+	return nil
+}
+
+func (c *idleConn) Ping(context.Context) error {
+	if c.use() != nil {
+		return sqldriver.ErrBadConn
+	}
+	return nil
+}
+
+func (c *idleConn) QueryContext(context.Context, string, []sqldriver.NamedValue) (sqldriver.Rows, error) {
+	if err := c.use(); err != nil {
+		idleFailures.Add(1)
+		return nil, err
+	}
+	return idleRows{}, nil
+}
+
+func (c *idleConn) Prepare(string) (sqldriver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *idleConn) Close() error                           { return nil }
+func (c *idleConn) Begin() (sqldriver.Tx, error)           { return nil, errors.New("not supported") }
+
+type idleRows struct{}
+
+func (idleRows) Columns() []string            { return []string{"id"} }
+func (idleRows) Close() error                 { return nil }
+func (idleRows) Next([]sqldriver.Value) error { return io.EOF }
+
+func TestQueryAfterIdle(t *testing.T) {
+	defer func(orig string) { driver = orig }(driver)
+	driver = "crt-idle"
+
+	tests := []struct {
+		name     string
+		maxIdle  time.Duration
+		failures int32 // Queries failed on the dropped connection before reconnecting
+	}{
+		{"reused stale connection", 0, 1},
+		{"reconnected before querying", 50 * time.Millisecond, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idleFailures.Store(0)
+			cfg := Config{MaxIdle: tt.maxIdle}
+			db, err := connect(cfg, "idle.example")
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := &Repository{dbs: []*sql.DB{db}, addrs: []string{"idle.example"}, cfg: cfg}
+			defer func() { r.host(0).Close() }()
+
+			// Past the server timeout, and the pool's cleaner running at least once
+			time.Sleep(time.Second + 2*serverIdleTimeout)
+
+			// Both succeed, but the stale connection only after failing once and reconnecting
+			rows, err := r.queryHost(context.Background(), 0, "SELECT 1")
+			if err != nil {
+				t.Fatalf("query after idle: %v", err)
+			}
+			rows.Close()
+			if got := idleFailures.Load(); got != tt.failures {
+				t.Errorf("%d queries failed on a dropped connection, want %d", got, tt.failures)
+			}
+		})
+	}
+}
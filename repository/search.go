@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/pkgforge-security/crt/result"
+)
+
+// search returns the certificates matching condition (one of the *Search
+// conditions of searchScript) for value, newest first; with the HTTP API, it
+// searches the crt.sh param instead, if it has one
+func (r *Repository) search(ctx context.Context, name, condition, param, value string, expired bool, limit int) (result.Certificates, error) {
+	startTime := time.Now()
+
+	var res result.Certificates
+	if r.api != nil {
+		if param == "" {
+			return nil, fmt.Errorf("%s is not supported by the HTTP API, use -backend db", name)
+		}
+		certs, err := r.api.search(ctx, url.Values{param: {value}}, expired)
+		if err != nil {
+			return nil, err
+		}
+		for _, cert := range certs {
+			if len(res) == limit {
+				break
+			}
+			if r.Filter.keep(cert) {
+				res = append(res, cert)
+			}
+		}
+		res.FlagImplausibleDates()
+		r.cfg.log().Debug("Queried "+name+" (HTTP)", "value", value, "took", time.Since(startTime).Round(time.Millisecond))
+		return res, nil
+	}
+
+	if len(r.dbs) == 0 {
+		return nil, errors.New("Database Connection is nil")
+	}
+
+	filter, args := r.whereFilter(expired, []interface{}{value, limit})
+	stmt := r.comment + fmt.Sprintf(searchScript, condition, filter)
+
+	rows, done, err := r.query(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to query db: %w", err)
+	}
+	defer done()
+	defer rows.Close()
+
+	if res, err = scanCertificates(rows); err != nil {
+		return nil, err
+	}
+	r.cfg.log().Debug("Queried "+name, "value", value, "took", time.Since(startTime).Round(time.Millisecond))
+	return res, nil
+}
+
+// GetBySerial returns the certificates with the serial number serial, given
+// in any common hex notation (e.g. 03:A1:FF or 03a1ff)
+func (r *Repository) GetBySerial(ctx context.Context, serial string, expired bool, limit int) (result.Certificates, error) {
+	serial = result.FormatSerial(serial, result.SerialHex)
+	return r.search(ctx, "GetBySerial", serialSearch, "serial", serial, expired, limit)
+}
+
+// GetByIssuer returns the certificates whose issuer name contains issuer
+// (case-insensitive), e.g. "Let's Encrypt" or "CN=R3"
+func (r *Repository) GetByIssuer(ctx context.Context, issuer string, expired bool, limit int) (result.Certificates, error) {
+	return r.search(ctx, "GetByIssuer", issuerSearch, "", issuer, expired, limit)
+}
+
+// GetByOrg returns the certificates issued to the organization (subject O=)
+// org, matched case-insensitively
+func (r *Repository) GetByOrg(ctx context.Context, org string, expired bool, limit int) (result.Certificates, error) {
+	return r.search(ctx, "GetByOrg", orgSearch, "O", org, expired, limit)
+}
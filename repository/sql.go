@@ -11,8 +11,8 @@ const (
 		encode(x509_serialNumber(sub.CERTIFICATE), 'hex') SERIAL_NUMBER
 	FROM (SELECT *
 			FROM certificate_and_identities cai
-			WHERE plainto_tsquery('certwatch', '%s') @@ identities(cai.CERTIFICATE)
-				AND cai.NAME_VALUE ILIKE ('%%' || '%s' || '%%')
+			WHERE plainto_tsquery('certwatch', $1) @@ identities(cai.CERTIFICATE)
+				AND cai.NAME_VALUE ILIKE ('%%' || $2::text || '%%')
 				%s --filter
 			LIMIT 10000
 		) sub
@@ -36,15 +36,58 @@ FROM ci
 	ca
 WHERE ci.ISSUER_CA_ID = ca.ID
 ORDER BY le.ENTRY_TIMESTAMP DESC NULLS LAST
-LIMIT %d`
+LIMIT $3`
 
 	subdomainScript = `SELECT DISTINCT cai.NAME_VALUE
 FROM certificate_and_identities cai
-WHERE plainto_tsquery('certwatch', '%s') @@ identities(cai.CERTIFICATE)
-	AND cai.NAME_VALUE ILIKE ('%%' || '%s' || '%%')
+WHERE plainto_tsquery('certwatch', $1) @@ identities(cai.CERTIFICATE)
+	AND cai.NAME_VALUE ILIKE ('%%' || $2::text || '%%')
 	%s --filter
-LIMIT %d`
+LIMIT $3`
 
 	excludeExpiredFilter = `AND coalesce(x509_notAfter(cai.CERTIFICATE), 'infinity'::timestamp) >= date_trunc('year', now() AT TIME ZONE 'UTC')
 	AND x509_notAfter(cai.CERTIFICATE) >= now() AT TIME ZONE 'UTC'`
+
+	minCertIDFilter = `AND cai.CERTIFICATE_ID >= $%d`
+	maxCertIDFilter = `AND cai.CERTIFICATE_ID <= $%d`
+
+	// Entry timestamp bounds, on the first CT log entry like ENTRY_TIMESTAMP
+	sinceFilter = `AND (SELECT min(ctle.ENTRY_TIMESTAMP) FROM ct_log_entry ctle WHERE ctle.CERTIFICATE_ID = cai.CERTIFICATE_ID) >= $%d`
+	untilFilter = `AND (SELECT min(ctle.ENTRY_TIMESTAMP) FROM ct_log_entry ctle WHERE ctle.CERTIFICATE_ID = cai.CERTIFICATE_ID) < $%d`
+
+	expiringFilter = `AND x509_notAfter(cai.CERTIFICATE) >= now() AT TIME ZONE 'UTC'
+	AND x509_notAfter(cai.CERTIFICATE) < $%d`
+)
+
+// searchScript returns certificates found by a condition on the certificate
+// table (cai.CERTIFICATE, cai.ISSUER_CA_ID), with the columns of certLogScript
+const searchScript = `SELECT cai.ISSUER_CA_ID,
+	ca.NAME ISSUER_NAME,
+	x509_commonName(cai.CERTIFICATE) COMMON_NAME,
+	(SELECT array_to_string(array_agg(DISTINCT names.NAME_VALUE), chr(10))
+		FROM certificate_and_identities names
+		WHERE names.CERTIFICATE_ID = cai.CERTIFICATE_ID) NAME_VALUE,
+	cai.CERTIFICATE_ID ID,
+	(SELECT min(ctle.ENTRY_TIMESTAMP) FROM ct_log_entry ctle WHERE ctle.CERTIFICATE_ID = cai.CERTIFICATE_ID) ENTRY_TIMESTAMP,
+	x509_notBefore(cai.CERTIFICATE) NOT_BEFORE,
+	x509_notAfter(cai.CERTIFICATE) NOT_AFTER,
+	encode(x509_serialNumber(cai.CERTIFICATE), 'hex') SERIAL_NUMBER
+FROM (SELECT c.ID CERTIFICATE_ID, c.CERTIFICATE, c.ISSUER_CA_ID
+		FROM certificate c
+	) cai,
+	ca
+WHERE cai.ISSUER_CA_ID = ca.ID
+	AND %s --search
+	%s --filter
+ORDER BY cai.CERTIFICATE_ID DESC -- Newest first, without sorting every match by log entry
+LIMIT $2`
+
+// Conditions of searchScript, on the search value $1
+const (
+	serialSearch = `x509_serialNumber(cai.CERTIFICATE) = decode($1, 'hex')`
+	issuerSearch = `strpos(lower(ca.NAME), lower($1)) > 0`
+	orgSearch    = `plainto_tsquery('certwatch', $1) @@ identities(cai.CERTIFICATE)
+	AND EXISTS (SELECT 1
+		FROM x509_nameAttributes(cai.CERTIFICATE, 'organizationName', TRUE) org
+		WHERE lower(org) = lower($1))`
 )
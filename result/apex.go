@@ -0,0 +1,52 @@
+package result
+
+import "strings"
+
+// multiLabelSuffixes are common public suffixes made of two labels, under
+// which the registrable domain has three labels (e.g. example.co.uk)
+var multiLabelSuffixes = map[string]bool{
+	"co.uk": true, "org.uk": true, "ac.uk": true, "gov.uk": true, "me.uk": true, "ltd.uk": true, "plc.uk": true,
+	"com.au": true, "net.au": true, "org.au": true, "edu.au": true, "gov.au": true,
+	"co.nz": true, "org.nz": true, "govt.nz": true, "ac.nz": true,
+	"co.jp": true, "ne.jp": true, "or.jp": true, "ac.jp": true, "go.jp": true,
+	"co.kr": true, "or.kr": true, "go.kr": true,
+	"com.br": true, "net.br": true, "org.br": true, "gov.br": true,
+	"com.cn": true, "net.cn": true, "org.cn": true, "gov.cn": true, "edu.cn": true,
+	"com.hk": true, "com.sg": true, "com.tw": true, "com.my": true, "com.mx": true, "com.ar": true,
+	"com.tr": true, "com.ua": true, "com.pl": true, "co.in": true, "net.in": true, "org.in": true, "gov.in": true,
+	"co.za": true, "org.za": true, "gov.za": true, "co.il": true, "ac.il": true, "co.id": true, "or.id": true,
+	"com.vn": true, "com.ph": true, "com.pk": true, "com.sa": true, "com.eg": true, "com.ng": true,
+}
+
+// ApexDomain returns the registrable domain of a hostname, e.g.
+// www.example.com -> example.com and a.b.example.co.uk -> example.co.uk
+func ApexDomain(name string) string {
+	name = strings.TrimPrefix(strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), ".")), "*.")
+	labels := strings.Split(name, ".")
+
+	n := 2
+	if len(labels) >= 3 && multiLabelSuffixes[strings.Join(labels[len(labels)-2:], ".")] {
+		n = 3
+	}
+	if len(labels) <= n {
+		return name
+	}
+	return strings.Join(labels[len(labels)-n:], ".")
+}
+
+// Hostnames returns every hostname in the results: all SANs of certificates,
+// or the enumerated subdomains
+func Hostnames(res Printer) []string {
+	var names []string
+	switch res := res.(type) {
+	case Certificates:
+		for _, cert := range res {
+			names = append(names, cert.sanList()...)
+		}
+	case Subdomains:
+		for _, sub := range res {
+			names = append(names, sub.Name)
+		}
+	}
+	return names
+}
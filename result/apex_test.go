@@ -0,0 +1,26 @@
+package result
+
+import "testing"
+
+func TestApexDomain(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"example.com", "example.com"},
+		{"www.example.com", "example.com"},
+		{"a.b.c.example.com", "example.com"},
+		{"*.example.com", "example.com"},
+		{"WWW.Example.COM.", "example.com"},
+		{"a.b.example.co.uk", "example.co.uk"},
+		{"example.co.uk", "example.co.uk"},
+		{"co.uk", "co.uk"},
+		{"localhost", "localhost"},
+	}
+
+	for _, tt := range tests {
+		if got := ApexDomain(tt.name); got != tt.want {
+			t.Errorf("ApexDomain(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
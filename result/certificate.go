@@ -2,173 +2,529 @@ package result
 
 import (
 	"bytes"
-	"encoding/csv"
-	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
-
-	"github.com/olekukonko/tablewriter"
 )
 
 type Certificate struct {
-	IssuerCaID            int       `json:"issuer_ca_id"`
-	IssuerName            string    `json:"issuer_name"`
-	CommonName            string    `json:"common_name"`
-	NameValue             string    `json:"name_value"`
-	ID                    int       `json:"id"`
-	EntryTimestamp        time.Time `json:"entry_timestamp"`
-	NotBefore             time.Time `json:"not_before"`
-	NotAfter              time.Time `json:"not_after"`
-	SerialNumber          string    `json:"serial_number"`
-	NewlyRegisteredDomain string    `json:"nrd,omitempty"`
+	Domain                string    `json:"domain,omitempty" yaml:"domain,omitempty"`
+	IssuerCaID            int       `json:"issuer_ca_id" yaml:"issuer_ca_id"`
+	IssuerName            string    `json:"issuer_name" yaml:"issuer_name"`
+	IssuerOrganization    string    `json:"issuer_org,omitempty" yaml:"issuer_org,omitempty"`
+	CommonName            string    `json:"common_name" yaml:"common_name"`
+	NameValue             string    `json:"name_value" yaml:"name_value"`
+	ID                    int       `json:"id" yaml:"id"`
+	EntryTimestamp        time.Time `json:"entry_timestamp" yaml:"entry_timestamp"`
+	NotBefore             time.Time `json:"not_before" yaml:"not_before"`
+	NotAfter              time.Time `json:"not_after" yaml:"not_after"`
+	SerialNumber          string    `json:"serial_number" yaml:"serial_number"`
+	NewlyRegisteredDomain string    `json:"nrd,omitempty" yaml:"nrd,omitempty"`
+	Category              string    `json:"category,omitempty" yaml:"category,omitempty"`
+	QueriedAt             string    `json:"queried_at,omitempty" yaml:"queried_at,omitempty"`
+	ImplausibleDates      bool      `json:"implausible_dates,omitempty" yaml:"implausible_dates,omitempty"`
+	SANs                  []string  `json:"sans,omitempty" yaml:"sans,omitempty"`
+	URL                   string    `json:"crtsh_url,omitempty" yaml:"crtsh_url,omitempty"`
 }
 
 type Certificates []Certificate
 
-func (r Certificates) Table() []byte {
-	res := new(bytes.Buffer)
-	table := tablewriter.NewWriter(res)
-
-	// Add NRD indicator to header if this is a newly registered domain
-	var info []string
-	if len(r) > 0 && len(r) <= 2 {
-		// Mark as newly registered domain
-		info = []string{"Matching", "Logged At", "Not Before", "Not After", "Issuer", "NRD"}
-		// Set the NewlyRegisteredDomain field for the single certificate
-		r[0].NewlyRegisteredDomain = "likely"
-	} else {
-		info = []string{"Matching", "Logged At", "Not Before", "Not After", "Issuer"}
+// SetDomain records domain as the looked up domain of every certificate
+func (r Certificates) SetDomain(domain string) {
+	for i := range r {
+		r[i].Domain = domain
 	}
+}
 
-	table.SetHeader(info)
-	table.SetFooter(info)
-
-	blue := tablewriter.Color(tablewriter.FgHiBlueColor)
-	yellow := tablewriter.Color(tablewriter.FgHiYellowColor)
-	white := tablewriter.Color(tablewriter.FgWhiteColor)
-	red := tablewriter.Color(tablewriter.FgHiRedColor)
+// CrtShURL returns the crt.sh page of the certificate, with its full details
+func (c Certificate) CrtShURL() string {
+	return fmt.Sprintf("https://crt.sh/?id=%d", c.ID)
+}
 
-	// Set colors for each column
-	if len(r) > 0 && len(r) <= 2 {
-		table.SetHeaderColor(blue, blue, blue, blue, blue, blue)
-		table.SetFooterColor(blue, blue, blue, blue, blue, blue)
-		table.SetColumnColor(yellow, white, white, white, white, red)
-	} else {
-		table.SetHeaderColor(blue, blue, blue, blue, blue)
-		table.SetFooterColor(blue, blue, blue, blue, blue)
-		table.SetColumnColor(yellow, white, white, white, white)
-	}
+// table renders the cells as a table with a footer, summarizing the SANs of
+// certificates with more than Options.SANSummary of them below the table
+func (r Certificates) table(o Options) []byte {
+	info, rows := r.cells(o)
 
-	for _, cert := range r {
-		// Extract issuer organization more safely
-		issuerOrg := "Unknown"
-		if strings.Contains(cert.IssuerName, "O=") {
-			parts := strings.Split(cert.IssuerName, "O=")
-			if len(parts) > 1 {
-				// Further split by comma and get the first part
-				commaParts := strings.Split(parts[1], ",")
-				if len(commaParts) > 0 {
-					issuerOrg = strings.Trim(commaParts[0], "\"")
-				}
+	// SAN lists summarized in the table, printed in full below it
+	var sanDetails [][]string
+	if o.SANSummary > 0 {
+		matching := 0
+		if o.Domain {
+			matching = 1
+		}
+		for i, cert := range r {
+			if sans := cert.sanList(); len(sans) > o.SANSummary {
+				sanDetails = append(sanDetails, sans)
+				rows[i][matching] = fmt.Sprintf("%d SANs [%d]", len(sans), len(sanDetails))
 			}
 		}
+	}
 
-		//row := []string{
-		//	cert.NameValue,
-		//	cert.EntryTimestamp.String()[0:20],
-		//	cert.NotBefore.String()[0:10],
-		//	cert.NotAfter.String()[0:10],
-		//	issuerOrg,
-		//}
+	res := bytes.NewBuffer(o.renderTable(info, rows, !o.NoFooter))
+	for i, sans := range sanDetails {
+		fmt.Fprintf(res, "[%d] %s\n", i+1, strings.Join(sans, ", "))
+	}
+
+	return res.Bytes()
+}
+
+// cells returns the header and rows of the table formats
+func (r Certificates) cells(o Options) ([]string, [][]string) {
+	info := []string{"Matching", "Logged At", "Not Before", "Not After", "Issuer"}
+	if o.Domain {
+		info = append([]string{"Domain"}, info...)
+	}
+	if o.RelativeTime {
+		info = append(info, "Logged", "Expires")
+	}
+	if o.DaysLeft {
+		info = append(info, "Days Left")
+	}
+	if o.Categorize {
+		info = append(info, "Category")
+	}
+	if o.Verbose {
+		info = append(info, "ID", "crt.sh")
+	}
+	r, nrd := r.nrdOnce()
+	if nrd {
+		info = append(info, "NRD")
+	}
+
+	rows := make([][]string, 0, len(r))
+	for _, cert := range r {
 		row := []string{
 			cert.NameValue,
-			cert.EntryTimestamp.Format("2006-01-02 15:04:05"),
-			cert.NotBefore.Format("2006-01-02"),
-			cert.NotAfter.Format("2006-01-02"),
-			issuerOrg,
-		}		
-
-		// Add NRD indicator if this is the only result
-		if len(r) > 0 && len(r) <= 2 {
+			formatDate(cert.EntryTimestamp, "2006-01-02 15:04:05"),
+			formatDate(cert.NotBefore, "2006-01-02"),
+			formatDate(cert.NotAfter, "2006-01-02"),
+			cert.issuerLabel(),
+		}
+		if o.Domain {
+			row = append([]string{cert.Domain}, row...)
+		}
+		if o.RelativeTime {
+			now := time.Now()
+			row = append(row, relativeTime(cert.EntryTimestamp, now), relativeTime(cert.NotAfter, now))
+		}
+		if o.DaysLeft {
+			row = append(row, daysLeft(cert.NotAfter, time.Now()))
+		}
+		if o.Categorize {
+			row = append(row, cert.Category)
+		}
+		if o.Verbose {
+			row = append(row, strconv.Itoa(cert.ID), cert.CrtShURL())
+		}
+		if nrd {
 			row = append(row, cert.NewlyRegisteredDomain)
 		}
-
-		table.Append(row)
+		rows = append(rows, row)
 	}
 
-	table.SetRowLine(true)
-	table.SetRowSeparator("—")
-	table.Render()
-
-	return res.Bytes()
+	return info, rows
 }
 
-func (r Certificates) JSON() ([]byte, error) {
-	// If there's only one entry, mark it as newly registered domain
-	if len(r) > 0 && len(r) <= 2 {
-		r[0].NewlyRegisteredDomain = "likely"
+// records returns the value JSON and YAML encode: the certificates with their
+// issuer organization, crt.sh URL and NRD verdict, or only Options.Fields of them
+func (r Certificates) records(o Options) interface{} {
+	r, _ = r.withSerialFormat(o).nrdOnce()
+
+	// Add the SANs of certificates with many of them as a nested array
+	if o.SANSummary > 0 {
+		for i, cert := range r {
+			if sans := cert.sanList(); len(sans) > o.SANSummary {
+				r[i].SANs = sans
+			}
+		}
 	}
-	
-	res, err := json.MarshalIndent(r, "", "\t")
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal results: %s", err)
+
+	// Add the issuer organization and link each certificate to its crt.sh page
+	for i := range r {
+		r[i].IssuerOrganization = r[i].IssuerOrg()
+		r[i].URL = r[i].CrtShURL()
 	}
 
-	return res, nil
+	if len(o.Fields) > 0 {
+		return r.selectFields(o)
+	} else if o.StringIDs {
+		return r.withStringIDs()
+	}
+	return r
 }
 
-func (r Certificates) CSV() ([]byte, error) {
-	res := new(bytes.Buffer)
-	w := csv.NewWriter(res)
+// csvCells returns the header and rows of CSV, or only the Options.Fields
+// columns of them
+func (r Certificates) csvCells(o Options) ([]string, [][]string) {
+	r, nrd := r.withSerialFormat(o).nrdOnce()
 
-	// Add NRD to the header if this is a newly registered domain
+	if len(o.Fields) > 0 {
+		return r.fieldsCells(o)
+	}
+
+	// Add NRD to the header if this is a newly registered domain (or always,
+	// with Options.NRDColumn)
 	var headers []string
-	if len(r) > 0 && len(r) <= 2 {
-		r[0].NewlyRegisteredDomain = "likely"
+	nrd = nrd || o.NRDColumn
+	if nrd {
 		headers = []string{
-			"issuer_ca_id", "issuer_name", "common_name", "name_value", "id",
+			"issuer_ca_id", "issuer_name", "issuer_org", "common_name", "name_value", "id",
 			"entry_timestamp", "not_before", "not_after", "serial_number", "newly_registered_domain",
 		}
 	} else {
 		headers = []string{
-			"issuer_ca_id", "issuer_name", "common_name", "name_value", "id",
+			"issuer_ca_id", "issuer_name", "issuer_org", "common_name", "name_value", "id",
 			"entry_timestamp", "not_before", "not_after", "serial_number",
 		}
 	}
 
-	err := w.Write(headers)
-	if err != nil {
-		return nil, fmt.Errorf("failed to write CSV headers: %s", err)
+	if o.Categorize {
+		headers = append(headers, "category")
 	}
 
+	if o.Domain {
+		headers = append([]string{"domain"}, headers...)
+	}
+
+	if o.QueriedAt {
+		headers = append(headers, "queried_at")
+	}
+
+	rows := make([][]string, 0, len(r))
 	for _, v := range r {
 		row := []string{
 			strconv.Itoa(v.IssuerCaID),
 			v.IssuerName,
+			v.IssuerOrg(),
 			v.CommonName,
 			v.NameValue,
 			strconv.Itoa(v.ID),
-			v.EntryTimestamp.String(),
-			v.NotBefore.String(),
-			v.NotAfter.String(),
+			csvTime(v.EntryTimestamp),
+			csvTime(v.NotBefore),
+			csvTime(v.NotAfter),
 			v.SerialNumber,
 		}
 		
-		// Add NRD value if this is the only result
-		if len(r) > 0 && len(r) <= 2 {
+		// Add NRD value if this is a newly registered domain
+		if nrd {
 			row = append(row, v.NewlyRegisteredDomain)
 		}
+
+		if o.Categorize {
+			row = append(row, v.Category)
+		}
+
+		if o.QueriedAt {
+			row = append(row, v.QueriedAt)
+		}
+
+		if o.Domain {
+			row = append([]string{v.Domain}, row...)
+		}
 		
-		err = w.Write(row)
-		if err != nil {
-			return nil, fmt.Errorf("failed to write CSV content: %s", err)
+		rows = append(rows, row)
+	}
+
+	return headers, rows
+}
+
+// IssuerOrg returns the organization (O=) of the issuer name, unquoted, or
+// "" if it has none. Commas inside quoted values don't end the component:
+//
+//	C=US, O="DigiCert, Inc.", CN=DigiCert TLS RSA SHA256 2020 CA1 → DigiCert, Inc.
+func (c Certificate) IssuerOrg() string {
+	for _, rdn := range splitDN(c.IssuerName) {
+		key, value, ok := strings.Cut(rdn, "=")
+		if ok && strings.EqualFold(strings.TrimSpace(key), "O") {
+			return unquoteDN(strings.TrimSpace(value))
+		}
+	}
+	return ""
+}
+
+// issuerLabel is the issuer organization shown in tables
+func (c Certificate) issuerLabel() string {
+	if org := c.IssuerOrg(); org != "" {
+		return org
+	}
+	return "Unknown"
+}
+
+// splitDN splits a distinguished name into its components, at the commas
+// (and + of multi-valued components) outside of quotes and escapes
+func splitDN(dn string) []string {
+	var parts []string
+	var quoted, escaped bool
+	start := 0
+	for i, r := range dn {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case (r == ',' || r == '+') && !quoted:
+			parts = append(parts, dn[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, dn[start:])
+}
+
+// unquoteDN removes the quotes and escapes around a component's value
+func unquoteDN(value string) string {
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		value = value[1 : len(value)-1]
+	}
+
+	var res strings.Builder
+	escaped := false
+	for _, r := range value {
+		if r == '\\' && !escaped {
+			escaped = true
+			continue
 		}
+		escaped = false
+		res.WriteRune(r)
 	}
-	w.Flush()
+	return res.String()
+}
 
-	return res.Bytes(), nil
+// sanList returns the names in NameValue, one per line
+func (c Certificate) sanList() []string {
+	if c.NameValue == "" {
+		return nil
+	}
+	return strings.Split(c.NameValue, "\n")
 }
 
-func (r Certificates) Size() int { return len(r) }
\ No newline at end of file
+// ExpandSANs returns one copy of each certificate per name in its NameValue,
+// with all other fields kept as they are
+func (r Certificates) ExpandSANs() Certificates {
+	res := make(Certificates, 0, len(r))
+	for _, cert := range r {
+		names := cert.sanList()
+		if len(names) <= 1 {
+			res = append(res, cert)
+			continue
+		}
+		for _, name := range names {
+			clone := cert
+			clone.NameValue = name
+			clone.SANs = nil
+			res = append(res, clone)
+		}
+	}
+	return res
+}
+
+// Serial number formats for Options.SerialFormat
+const (
+	SerialHex   = "hex"   // Lowercase hex without separators, e.g. 03a1ff
+	SerialColon = "colon" // Lowercase hex bytes separated by colons, e.g. 03:a1:ff
+)
+
+// FormatSerial normalizes a serial number given in any common hex notation
+// (upper/lower case, with or without colons, spaces or 0x) to format
+func FormatSerial(serial, format string) string {
+	hex := strings.ToLower(strings.TrimSpace(serial))
+	hex = strings.TrimPrefix(hex, "0x")
+	hex = strings.NewReplacer(":", "", " ", "", "-", "").Replace(hex)
+	if len(hex)%2 == 1 {
+		hex = "0" + hex
+	}
+
+	if format != SerialColon {
+		return hex
+	}
+
+	pairs := make([]string, 0, len(hex)/2)
+	for i := 0; i < len(hex); i += 2 {
+		pairs = append(pairs, hex[i:i+2])
+	}
+	return strings.Join(pairs, ":")
+}
+
+// withSerialFormat returns a copy of the certificates with their serial
+// numbers normalized to Options.SerialFormat, or r itself if it isn't set
+func (r Certificates) withSerialFormat(o Options) Certificates {
+	if o.SerialFormat == "" {
+		return r
+	}
+
+	res := make(Certificates, len(r))
+	for i, cert := range r {
+		cert.SerialNumber = FormatSerial(cert.SerialNumber, o.SerialFormat)
+		res[i] = cert
+	}
+	return res
+}
+
+// plainCertificate has the fields of Certificate without its methods
+type plainCertificate Certificate
+
+// certificateStringIDs shadows the numeric IDs of a certificate with strings,
+// so that JavaScript consumers don't lose precision on large values
+type certificateStringIDs struct {
+	plainCertificate
+	IssuerCaID string `json:"issuer_ca_id" yaml:"issuer_ca_id"`
+	ID         string `json:"id" yaml:"id"`
+}
+
+func (r Certificates) withStringIDs() []certificateStringIDs {
+	res := make([]certificateStringIDs, 0, len(r))
+	for _, cert := range r {
+		res = append(res, certificateStringIDs{
+			plainCertificate: plainCertificate(cert),
+			IssuerCaID:       strconv.Itoa(cert.IssuerCaID),
+			ID:               strconv.Itoa(cert.ID),
+		})
+	}
+	return res
+}
+
+// fieldsCells returns only the Options.Fields columns of the certificates
+func (r Certificates) fieldsCells(o Options) ([]string, [][]string) {
+	var rows [][]string
+	for _, record := range r.selectFields(o) {
+		row := make([]string, len(record.values))
+		for i, v := range record.values {
+			row[i] = fieldString(v)
+		}
+		rows = append(rows, row)
+	}
+
+	return o.Fields, rows
+}
+
+// Dates outside [minPlausibleDate, now+maxPlausibleYears] are treated as malformed
+var minPlausibleDate = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const maxPlausibleYears = 50
+
+// plausibleDate reports whether t is a believable certificate date
+func plausibleDate(t time.Time) bool {
+	return !t.Before(minPlausibleDate) && !t.After(time.Now().AddDate(maxPlausibleYears, 0, 0))
+}
+
+// formatDate formats t, or marks it as invalid if it is implausible
+func formatDate(t time.Time, layout string) string {
+	if !plausibleDate(t) {
+		return "⚠️ invalid"
+	}
+	return t.Format(layout)
+}
+
+// FlagImplausibleDates marks certificates with zero, pre-2000 or far-future
+// (more than 50 years ahead) entry, not before or not after dates
+func (r Certificates) FlagImplausibleDates() {
+	for i, cert := range r {
+		r[i].ImplausibleDates = !plausibleDate(cert.EntryTimestamp) ||
+			!plausibleDate(cert.NotBefore) || !plausibleDate(cert.NotAfter)
+	}
+}
+
+// relativeTime describes t relative to now, e.g. "3d ago" or "in 45d"
+func relativeTime(t, now time.Time) string {
+	if !plausibleDate(t) {
+		return ""
+	}
+
+	d := now.Sub(t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+
+	var amount string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		amount = fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		amount = fmt.Sprintf("%dh", int(d.Hours()))
+	case d < 365*24*time.Hour:
+		amount = fmt.Sprintf("%dd", int(d.Hours()/24))
+	default:
+		amount = fmt.Sprintf("%dy", int(d.Hours()/(24*365)))
+	}
+
+	if future {
+		return "in " + amount
+	}
+	return amount + " ago"
+}
+
+// daysLeft formats the whole days from now until the certificate expires at
+// notAfter, as "12d" (or "expired")
+func daysLeft(notAfter, now time.Time) string {
+	if !plausibleDate(notAfter) {
+		return ""
+	}
+	if notAfter.Before(now) {
+		return "expired"
+	}
+	return fmt.Sprintf("%dd", int(notAfter.Sub(now).Hours()/24))
+}
+
+// Stamp records t (in RFC3339) as the time the certificates were queried
+func (r Certificates) Stamp(t time.Time) {
+	for i := range r {
+		r[i].QueriedAt = t.UTC().Format(time.RFC3339)
+	}
+}
+
+// Certificate categories set by Categorize
+const (
+	CategoryApex      = "apex"
+	CategorySubdomain = "subdomain"
+)
+
+// Categorize splits certificates into those covering the apex domain itself
+// and those only covering its subdomains, setting Category on each
+func (r Certificates) Categorize(apex string) (apexCerts, subdomainCerts Certificates) {
+	apex = strings.ToLower(strings.TrimSuffix(apex, "."))
+	for _, cert := range r {
+		cert.Category = CategorySubdomain
+		for _, name := range strings.Split(cert.NameValue, "\n") {
+			if strings.ToLower(strings.TrimSpace(name)) == apex {
+				cert.Category = CategoryApex
+				break
+			}
+		}
+
+		if cert.Category == CategoryApex {
+			apexCerts = append(apexCerts, cert)
+		} else {
+			subdomainCerts = append(subdomainCerts, cert)
+		}
+	}
+	return apexCerts, subdomainCerts
+}
+
+func (r Certificates) Table() []byte { return Options{}.Table(r) }
+
+func (r Certificates) Markdown() []byte { return Options{}.Markdown(r) }
+
+func (r Certificates) HTML() []byte { return Options{}.HTML(r) }
+
+func (r Certificates) JSON() ([]byte, error) { return Options{}.JSON(r) }
+
+func (r Certificates) YAML() ([]byte, error) { return Options{}.YAML(r) }
+
+func (r Certificates) CSV() ([]byte, error) { return Options{}.CSV(r) }
+
+func (r Certificates) TSV() ([]byte, error) { return Options{}.TSV(r) }
+
+func (r Certificates) Size() int { return len(r) }
+
+func (r Certificates) Merge(other Printer) Printer {
+	if o, ok := other.(Certificates); ok {
+		return append(r, o...)
+	}
+	return r
+}
\ No newline at end of file
@@ -0,0 +1,347 @@
+package result
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sampleCerts are certificates of example.com for the rendering tests
+var sampleCerts = Certificates{
+	{
+		IssuerCaID: 183267, IssuerName: `C=US, O="DigiCert, Inc.", CN=DigiCert TLS RSA SHA256 2020 CA1`,
+		CommonName: "example.com", NameValue: "example.com\nwww.example.com", ID: 12345678901,
+		EntryTimestamp: time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC),
+		NotBefore:      time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:       time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		SerialNumber:   "0a1b2c",
+	},
+	{
+		IssuerCaID: 295810, IssuerName: "C=US, O=Let's Encrypt, CN=R3",
+		CommonName: "api.example.com", NameValue: "api.example.com", ID: 987,
+		EntryTimestamp: time.Date(2024, 5, 2, 8, 0, 0, 0, time.UTC),
+		NotBefore:      time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC),
+		NotAfter:       time.Date(2024, 7, 31, 0, 0, 0, 0, time.UTC),
+		SerialNumber:   "03f4",
+	},
+}
+
+func TestCertificatesTableFooter(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		headers int
+	}{
+		{"footer", Options{NoColor: true}, 2},
+		{"no footer", Options{NoColor: true, NoFooter: true}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := string(tt.opts.Table(sampleCerts))
+			if got := strings.Count(table, "MATCHING"); got != tt.headers {
+				t.Errorf("header printed %d times, want %d:\n%s", got, tt.headers, table)
+			}
+			if !strings.Contains(table, "api.example.com") {
+				t.Errorf("rows missing:\n%s", table)
+			}
+		})
+	}
+}
+
+func TestCategorize(t *testing.T) {
+	certs := Certificates{
+		{ID: 1, NameValue: "example.com\nwww.example.com"},
+		{ID: 2, NameValue: "api.example.com"},
+		{ID: 3, NameValue: "*.example.com\nEXAMPLE.COM"},
+		{ID: 4, NameValue: "*.example.com"},
+		{ID: 5, NameValue: "example.com.evil.net\nnotexample.com"},
+	}
+
+	tests := []struct {
+		apex       string
+		apexIDs    []int
+		subdomains []int
+	}{
+		{"example.com", []int{1, 3}, []int{2, 4, 5}},
+		{"Example.com.", []int{1, 3}, []int{2, 4, 5}},
+		{"api.example.com", []int{2}, []int{1, 3, 4, 5}},
+	}
+
+	ids := func(t *testing.T, certs Certificates, category string) []int {
+		var res []int
+		for _, cert := range certs {
+			if cert.Category != category {
+				t.Errorf("certificate %d in %s has category %q", cert.ID, category, cert.Category)
+			}
+			res = append(res, cert.ID)
+		}
+		return res
+	}
+	for _, tt := range tests {
+		t.Run(tt.apex, func(t *testing.T) {
+			apex, subs := certs.Categorize(tt.apex)
+			if got := ids(t, apex, CategoryApex); !reflect.DeepEqual(got, tt.apexIDs) {
+				t.Errorf("apex certificates = %v, want %v", got, tt.apexIDs)
+			}
+			if got := ids(t, subs, CategorySubdomain); !reflect.DeepEqual(got, tt.subdomains) {
+				t.Errorf("subdomain certificates = %v, want %v", got, tt.subdomains)
+			}
+		})
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		offset time.Duration
+		want   string
+	}{
+		{0, "just now"},
+		{-30 * time.Second, "just now"},
+		{-5 * time.Minute, "5m ago"},
+		{-3 * time.Hour, "3h ago"},
+		{-3 * 24 * time.Hour, "3d ago"},
+		{45 * 24 * time.Hour, "in 45d"},
+		{-400 * 24 * time.Hour, "1y ago"},
+		{90 * time.Minute, "in 1h"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := relativeTime(now.Add(tt.offset), now); got != tt.want {
+				t.Errorf("relativeTime(now%+v) = %q, want %q", tt.offset, got, tt.want)
+			}
+		})
+	}
+
+	if got := relativeTime(time.Time{}, now); got != "" {
+		t.Errorf("relativeTime(zero) = %q, want \"\"", got)
+	}
+}
+
+func TestFlagImplausibleDates(t *testing.T) {
+	valid := sampleCerts[0]
+	with := func(set func(c *Certificate)) Certificate {
+		c := valid
+		set(&c)
+		return c
+	}
+
+	tests := []struct {
+		name string
+		cert Certificate
+		want bool
+		cell string // Not After in the table
+	}{
+		{"valid", valid, false, "2025-03-01"},
+		{"zero not after", with(func(c *Certificate) { c.NotAfter = time.Time{} }), true, "⚠️ invalid"},
+		{"far-future not after", with(func(c *Certificate) { c.NotAfter = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC) }), true, "⚠️ invalid"},
+		{"zero entry timestamp", with(func(c *Certificate) { c.EntryTimestamp = time.Time{} }), true, "2025-03-01"},
+		{"pre-2000 not before", with(func(c *Certificate) { c.NotBefore = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC) }), true, "2025-03-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certs := Certificates{tt.cert}
+			certs.FlagImplausibleDates()
+			if certs[0].ImplausibleDates != tt.want {
+				t.Errorf("ImplausibleDates = %v, want %v", certs[0].ImplausibleDates, tt.want)
+			}
+
+			_, rows := certs.cells(Options{DaysLeft: true, RelativeTime: true})
+			if rows[0][3] != tt.cell {
+				t.Errorf("Not After cell = %q, want %q", rows[0][3], tt.cell)
+			}
+			if tt.cell != "2025-03-01" && (rows[0][6] != "" || rows[0][7] != "") {
+				t.Errorf("relative expiry and days left of an invalid date = %q, %q, want none", rows[0][6], rows[0][7])
+			}
+		})
+	}
+}
+
+func TestCertificatesTableCompact(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		borders bool
+	}{
+		{"default", Options{NoColor: true}, true},
+		{"compact", Options{NoColor: true, Compact: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := string(tt.opts.Table(sampleCerts))
+			for _, sep := range []string{"—", "+", "|"} {
+				if got := strings.Contains(table, sep); got != tt.borders {
+					t.Errorf("%q in table = %v, want %v:\n%s", sep, got, tt.borders, table)
+				}
+			}
+			if !strings.Contains(table, "api.example.com") {
+				t.Errorf("rows missing:\n%s", table)
+			}
+		})
+	}
+}
+
+func TestJSONStringIDs(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		id   string
+		caID string
+	}{
+		{"numbers", Options{}, "12345678901", "183267"},
+		{"strings", Options{StringIDs: true}, `"12345678901"`, `"183267"`},
+		{"selected fields", Options{StringIDs: true, Fields: []string{"id", "issuer_ca_id"}}, `"12345678901"`, `"183267"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.opts.JSON(sampleCerts[:1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			var records []map[string]json.RawMessage
+			if err := json.Unmarshal(data, &records); err != nil {
+				t.Fatal(err)
+			}
+			if got := string(records[0]["id"]); got != tt.id {
+				t.Errorf("id = %s, want %s", got, tt.id)
+			}
+			if got := string(records[0]["issuer_ca_id"]); got != tt.caID {
+				t.Errorf("issuer_ca_id = %s, want %s", got, tt.caID)
+			}
+		})
+	}
+}
+
+func TestFormatSerial(t *testing.T) {
+	tests := []struct {
+		serial string
+		hex    string
+		colon  string
+	}{
+		{"03A1FF", "03a1ff", "03:a1:ff"},
+		{"03:a1:ff", "03a1ff", "03:a1:ff"},
+		{"0x3a1ff", "03a1ff", "03:a1:ff"},
+		{" 03 A1 FF ", "03a1ff", "03:a1:ff"},
+		{"0a1b2c3d4e5f60718293a4b5c6d7e8f9", "0a1b2c3d4e5f60718293a4b5c6d7e8f9", "0a:1b:2c:3d:4e:5f:60:71:82:93:a4:b5:c6:d7:e8:f9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.serial, func(t *testing.T) {
+			if got := FormatSerial(tt.serial, SerialHex); got != tt.hex {
+				t.Errorf("hex = %q, want %q", got, tt.hex)
+			}
+			if got := FormatSerial(tt.serial, SerialColon); got != tt.colon {
+				t.Errorf("colon = %q, want %q", got, tt.colon)
+			}
+		})
+	}
+
+	// The certificates themselves keep their serial numbers
+	csv, err := Options{SerialFormat: SerialColon}.CSV(sampleCerts[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(csv), ",0a:1b:2c\n") || sampleCerts[0].SerialNumber != "0a1b2c" {
+		t.Errorf("CSV serial of %q:\n%s", sampleCerts[0].SerialNumber, csv)
+	}
+}
+
+func TestSANSummary(t *testing.T) {
+	many := Certificate{ID: 1, CommonName: "example.com", NameValue: "example.com\na.example.com\nb.example.com\nc.example.com", NotAfter: sampleCerts[0].NotAfter}
+	few := Certificate{ID: 2, CommonName: "x.example.com", NameValue: "x.example.com", NotAfter: sampleCerts[0].NotAfter}
+	certs := Certificates{many, few}
+
+	tests := []struct {
+		name    string
+		summary int
+		details bool // Summarized in the table, with the full SAN list below it and in JSON
+	}{
+		{"off", 0, false},
+		{"below the threshold", 4, false},
+		{"summarized", 3, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := Options{NoColor: true, NoFooter: true, SANSummary: tt.summary}
+			table := string(o.Table(certs))
+
+			if got := strings.Contains(table, "4 SANs [1]"); got != tt.details {
+				t.Errorf("summary in table = %v, want %v:\n%s", got, tt.details, table)
+			}
+			if got := strings.Contains(table, "[1] example.com, a.example.com, b.example.com, c.example.com\n"); got != tt.details {
+				t.Errorf("full SAN list below the table = %v, want %v:\n%s", got, tt.details, table)
+			}
+			if !strings.Contains(table, "x.example.com") || strings.Contains(table, "[2]") {
+				t.Errorf("certificate with few SANs changed:\n%s", table)
+			}
+
+			data, err := o.JSON(certs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var records []Certificate
+			if err := json.Unmarshal(data, &records); err != nil {
+				t.Fatal(err)
+			}
+			if got := len(records[0].SANs) == 4 && records[0].NameValue == many.NameValue; got != tt.details {
+				t.Errorf("full detail in JSON = %v, want %v: %+v", got, tt.details, records[0])
+			}
+			if records[1].SANs != nil {
+				t.Errorf("SANs of a certificate with few = %v", records[1].SANs)
+			}
+		})
+	}
+}
+
+func TestIssuerOrg(t *testing.T) {
+	tests := []struct {
+		issuer string
+		want   string
+	}{
+		{"C=US, O=Let's Encrypt, CN=R3", "Let's Encrypt"},
+		{"C=US, O=Let's Encrypt, CN=E5", "Let's Encrypt"},
+		{`C=US, O="DigiCert, Inc.", CN=DigiCert TLS RSA SHA256 2020 CA1`, "DigiCert, Inc."},
+		{`C=US, O="DigiCert Inc", OU=www.digicert.com, CN=GeoTrust TLS RSA CA G1`, "DigiCert Inc"},
+		{"C=GB, ST=Greater Manchester, L=Salford, O=Sectigo Limited, CN=Sectigo RSA Domain Validation Secure Server CA", "Sectigo Limited"},
+		{"C=US, O=Google Trust Services, CN=WR2", "Google Trust Services"},
+		{`C=US, O=Acme\, Inc., CN=Acme CA`, "Acme, Inc."},
+		{`CN=Example CA+O="Multi, Valued", C=US`, "Multi, Valued"},
+		{"c=US, o=lowercase keys, cn=CA", "lowercase keys"},
+		{"C=US, OU=Only a unit, CN=No Org CA", ""},
+		{"CN=Self-Signed", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.issuer, func(t *testing.T) {
+			if got := (Certificate{IssuerName: tt.issuer}).IssuerOrg(); got != tt.want {
+				t.Errorf("IssuerOrg(%q) = %q, want %q", tt.issuer, got, tt.want)
+			}
+		})
+	}
+
+	// Every format carries the organization, the table in place of the full DN
+	cert := sampleCerts[0]
+	if table := string(Options{NoColor: true}.Table(Certificates{cert})); !strings.Contains(table, "DigiCert, Inc.") || strings.Contains(table, "CN=DigiCert") {
+		t.Errorf("table issuer:\n%s", table)
+	}
+	data, err := Options{}.JSON(Certificates{cert})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []map[string]interface{}
+	if err := json.Unmarshal(data, &records); err != nil || records[0]["issuer_org"] != "DigiCert, Inc." {
+		t.Errorf("JSON issuer_org of %s", data)
+	}
+	if csv, err := (Options{}).CSV(Certificates{cert}); err != nil || !strings.Contains(string(csv), `,"DigiCert, Inc.",`) {
+		t.Errorf("CSV issuer_org of %s (%v)", csv, err)
+	}
+}
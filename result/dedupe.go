@@ -0,0 +1,62 @@
+package result
+
+import "strings"
+
+// Dedupe collapses certificates with the same name_value into one, keeping
+// the most recent: the one with the latest entry_timestamp (when it was
+// logged). On equal timestamps the higher crt.sh ID wins, and on equal IDs
+// the first row. Certificates keep the position of their name_value's first
+// occurrence.
+func (r Certificates) Dedupe() Certificates {
+	index := make(map[string]int, len(r))
+	res := make(Certificates, 0, len(r))
+
+	for _, cert := range r {
+		i, seen := index[cert.NameValue]
+		if !seen {
+			index[cert.NameValue] = len(res)
+			res = append(res, cert)
+			continue
+		}
+
+		kept := res[i]
+		if cert.EntryTimestamp.After(kept.EntryTimestamp) ||
+			(cert.EntryTimestamp.Equal(kept.EntryTimestamp) && cert.ID > kept.ID) {
+			res[i] = cert
+		}
+	}
+	return res
+}
+
+// Dedupe drops subdomains that only differ in case or a trailing dot from an
+// earlier one. Wildcards (*.example.com) are kept apart from their base name.
+func (s Subdomains) Dedupe() Subdomains {
+	seen := make(map[string]bool, len(s))
+	res := make(Subdomains, 0, len(s))
+
+	for _, sub := range s {
+		key := strings.TrimSuffix(strings.ToLower(sub.Name), ".")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		res = append(res, sub)
+	}
+	return res
+}
+
+// DedupeIDs drops certificates with the same crt.sh ID as an earlier one,
+// e.g. when merging the results of overlapping queries
+func (r Certificates) DedupeIDs() Certificates {
+	seen := make(map[int]bool, len(r))
+	res := make(Certificates, 0, len(r))
+
+	for _, cert := range r {
+		if seen[cert.ID] {
+			continue
+		}
+		seen[cert.ID] = true
+		res = append(res, cert)
+	}
+	return res
+}
@@ -0,0 +1,123 @@
+package result
+
+import "strconv"
+
+// CertificateDiff holds the certificates added and removed since a previous run
+type CertificateDiff struct {
+	Added   Certificates `json:"added" yaml:"added"`
+	Removed Certificates `json:"removed" yaml:"removed"`
+}
+
+// Diff compares current against previous certificates by ID
+func Diff(previous, current Certificates) CertificateDiff {
+	before := make(map[int]bool, len(previous))
+	for _, cert := range previous {
+		before[cert.ID] = true
+	}
+	after := make(map[int]bool, len(current))
+	for _, cert := range current {
+		after[cert.ID] = true
+	}
+
+	diff := CertificateDiff{Added: Certificates{}, Removed: Certificates{}}
+	for _, cert := range current {
+		if !before[cert.ID] {
+			diff.Added = append(diff.Added, cert)
+			before[cert.ID] = true // Only once, even if returned for several domains
+		}
+	}
+	for _, cert := range previous {
+		if !after[cert.ID] {
+			diff.Removed = append(diff.Removed, cert)
+			after[cert.ID] = true
+		}
+	}
+	return diff
+}
+
+// changes returns the certificates with their +/- markers, added first
+func (d CertificateDiff) changes() ([]string, Certificates) {
+	markers := make([]string, 0, d.Size())
+	certs := make(Certificates, 0, d.Size())
+	for _, cert := range d.Added {
+		markers = append(markers, "+")
+		certs = append(certs, cert)
+	}
+	for _, cert := range d.Removed {
+		markers = append(markers, "-")
+		certs = append(certs, cert)
+	}
+	return markers, certs
+}
+
+// cells returns the header and rows of the table formats
+func (d CertificateDiff) cells(o Options) ([]string, [][]string) {
+	markers, certs := d.changes()
+	rows := make([][]string, 0, len(certs))
+	for i, cert := range certs {
+		rows = append(rows, []string{
+			markers[i],
+			cert.NameValue,
+			formatDate(cert.EntryTimestamp, "2006-01-02 15:04:05"),
+			formatDate(cert.NotBefore, "2006-01-02"),
+			formatDate(cert.NotAfter, "2006-01-02"),
+			cert.issuerLabel(),
+			strconv.Itoa(cert.ID),
+		})
+	}
+
+	return []string{"", "Matching", "Logged At", "Not Before", "Not After", "Issuer", "ID"}, rows
+}
+
+// csvCells returns the header and rows of CSV, with the change (+/-) first
+func (d CertificateDiff) csvCells(o Options) ([]string, [][]string) {
+	headers := []string{
+		"change", "issuer_ca_id", "issuer_name", "issuer_org", "common_name", "name_value", "id",
+		"entry_timestamp", "not_before", "not_after", "serial_number",
+	}
+
+	markers, certs := d.changes()
+	rows := make([][]string, 0, len(certs))
+	for i, v := range certs {
+		row := []string{
+			markers[i],
+			strconv.Itoa(v.IssuerCaID),
+			v.IssuerName,
+			v.IssuerOrg(),
+			v.CommonName,
+			v.NameValue,
+			strconv.Itoa(v.ID),
+			csvTime(v.EntryTimestamp),
+			csvTime(v.NotBefore),
+			csvTime(v.NotAfter),
+			v.SerialNumber,
+		}
+		rows = append(rows, row)
+	}
+
+	return headers, rows
+}
+
+func (d CertificateDiff) Table() []byte { return Options{}.Table(d) }
+
+func (d CertificateDiff) Markdown() []byte { return Options{}.Markdown(d) }
+
+func (d CertificateDiff) HTML() []byte { return Options{}.HTML(d) }
+
+func (d CertificateDiff) JSON() ([]byte, error) { return Options{}.JSON(d) }
+
+func (d CertificateDiff) YAML() ([]byte, error) { return Options{}.YAML(d) }
+
+func (d CertificateDiff) CSV() ([]byte, error) { return Options{}.CSV(d) }
+
+func (d CertificateDiff) TSV() ([]byte, error) { return Options{}.TSV(d) }
+
+func (d CertificateDiff) Size() int { return len(d.Added) + len(d.Removed) }
+
+func (d CertificateDiff) Merge(other Printer) Printer {
+	if o, ok := other.(CertificateDiff); ok {
+		d.Added = append(d.Added, o.Added...)
+		d.Removed = append(d.Removed, o.Removed...)
+	}
+	return d
+}
@@ -0,0 +1,70 @@
+package result
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	certs := func(ids ...int) Certificates {
+		res := make(Certificates, len(ids))
+		for i, id := range ids {
+			res[i] = Certificate{ID: id, NameValue: "example.com"}
+		}
+		return res
+	}
+	ids := func(certs Certificates) []int {
+		res := []int{}
+		for _, cert := range certs {
+			res = append(res, cert.ID)
+		}
+		return res
+	}
+
+	tests := []struct {
+		name     string
+		previous Certificates
+		current  Certificates
+		added    []int
+		removed  []int
+	}{
+		{"unchanged", certs(1, 2), certs(2, 1), []int{}, []int{}},
+		{"added and removed", certs(1, 2, 3), certs(2, 4, 5), []int{4, 5}, []int{1, 3}},
+		{"first run", nil, certs(1, 2), []int{1, 2}, []int{}},
+		{"all gone", certs(1, 2), nil, []int{}, []int{1, 2}},
+		{"duplicates", certs(1, 1), certs(2, 2, 3), []int{2, 3}, []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := Diff(tt.previous, tt.current)
+			if got := ids(diff.Added); !reflect.DeepEqual(got, tt.added) {
+				t.Errorf("added = %v, want %v", got, tt.added)
+			}
+			if got := ids(diff.Removed); !reflect.DeepEqual(got, tt.removed) {
+				t.Errorf("removed = %v, want %v", got, tt.removed)
+			}
+
+			// JSON always has both arrays, table rows are marked + then -
+			data, err := Options{}.JSON(diff)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded map[string][]Certificate
+			if err := json.Unmarshal(data, &decoded); err != nil || decoded["added"] == nil || decoded["removed"] == nil {
+				t.Errorf("JSON = %s, want added and removed arrays", data)
+			}
+
+			_, rows := diff.cells(Options{})
+			var markers string
+			for _, row := range rows {
+				markers += row[0]
+			}
+			if want := strings.Repeat("+", len(tt.added)) + strings.Repeat("-", len(tt.removed)); markers != want {
+				t.Errorf("table markers = %q, want %q", markers, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package result
+
+import (
+	"strconv"
+	"time"
+)
+
+// Expiry buckets, in the order they are reported
+const (
+	ExpiryExpired = "expired"
+	Expiry30Days  = "<30d"
+	Expiry90Days  = "<90d"
+	ExpiryValid   = "valid"
+)
+
+var expiryBuckets = []string{ExpiryExpired, Expiry30Days, Expiry90Days, ExpiryValid}
+
+type ExpiryGroup struct {
+	Domain string `json:"domain" yaml:"domain"`
+	Group  string `json:"group" yaml:"group"`
+	Count  int    `json:"count" yaml:"count"`
+}
+
+type ExpiryGroups []ExpiryGroup
+
+// expiryBucket returns the bucket a certificate expiring at notAfter falls in
+func expiryBucket(notAfter, now time.Time) string {
+	switch {
+	case notAfter.Before(now):
+		return ExpiryExpired
+	case notAfter.Before(now.AddDate(0, 0, 30)):
+		return Expiry30Days
+	case notAfter.Before(now.AddDate(0, 0, 90)):
+		return Expiry90Days
+	default:
+		return ExpiryValid
+	}
+}
+
+// GroupByExpiry counts the certificates of domain per expiry bucket
+func (r Certificates) GroupByExpiry(domain string, now time.Time) ExpiryGroups {
+	counts := make(map[string]int, len(expiryBuckets))
+	for _, cert := range r {
+		counts[expiryBucket(cert.NotAfter, now)]++
+	}
+
+	res := make(ExpiryGroups, 0, len(expiryBuckets))
+	for _, bucket := range expiryBuckets {
+		res = append(res, ExpiryGroup{Domain: domain, Group: bucket, Count: counts[bucket]})
+	}
+	return res
+}
+
+// cells returns the header and rows of the table formats
+func (g ExpiryGroups) cells(o Options) ([]string, [][]string) {
+	rows := make([][]string, 0, len(g))
+	for _, group := range g {
+		rows = append(rows, []string{group.Domain, group.Group, strconv.Itoa(group.Count)})
+	}
+
+	return []string{"Domain", "Expiry", "Certificates"}, rows
+}
+
+// csvCells returns the header and rows of CSV
+func (g ExpiryGroups) csvCells(o Options) ([]string, [][]string) {
+	rows := make([][]string, 0, len(g))
+	for _, group := range g {
+		rows = append(rows, []string{group.Domain, group.Group, strconv.Itoa(group.Count)})
+	}
+
+	return []string{"domain", "group", "count"}, rows
+}
+
+func (g ExpiryGroups) Table() []byte { return Options{}.Table(g) }
+
+func (g ExpiryGroups) Markdown() []byte { return Options{}.Markdown(g) }
+
+func (g ExpiryGroups) HTML() []byte { return Options{}.HTML(g) }
+
+func (g ExpiryGroups) JSON() ([]byte, error) { return Options{}.JSON(g) }
+
+func (g ExpiryGroups) YAML() ([]byte, error) { return Options{}.YAML(g) }
+
+func (g ExpiryGroups) CSV() ([]byte, error) { return Options{}.CSV(g) }
+
+func (g ExpiryGroups) TSV() ([]byte, error) { return Options{}.TSV(g) }
+
+func (g ExpiryGroups) Size() int { return len(g) }
+
+func (g ExpiryGroups) Merge(other Printer) Printer {
+	if o, ok := other.(ExpiryGroups); ok {
+		return append(g, o...)
+	}
+	return g
+}
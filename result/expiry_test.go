@@ -0,0 +1,50 @@
+package result
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGroupByExpiry(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	expiring := func(days ...int) Certificates {
+		certs := make(Certificates, len(days))
+		for i, d := range days {
+			certs[i].NotAfter = now.AddDate(0, 0, d)
+		}
+		return certs
+	}
+
+	tests := []struct {
+		name  string
+		certs Certificates
+		want  []int // expired, <30d, <90d, valid
+	}{
+		{"none", nil, []int{0, 0, 0, 0}},
+		{"mixed", expiring(-400, -1, 0, 10, 29, 30, 89, 90, 365), []int{2, 3, 2, 2}},
+		{"all valid", expiring(100, 200), []int{0, 0, 0, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := tt.certs.GroupByExpiry("example.com", now)
+
+			var buckets []string
+			var counts []int
+			for _, g := range groups {
+				if g.Domain != "example.com" {
+					t.Errorf("group %s of domain %q", g.Group, g.Domain)
+				}
+				buckets = append(buckets, g.Group)
+				counts = append(counts, g.Count)
+			}
+			if !reflect.DeepEqual(buckets, expiryBuckets) {
+				t.Errorf("buckets = %v, want %v", buckets, expiryBuckets)
+			}
+			if !reflect.DeepEqual(counts, tt.want) {
+				t.Errorf("counts = %v, want %v", counts, tt.want)
+			}
+		})
+	}
+}
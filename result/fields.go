@@ -0,0 +1,154 @@
+package result
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// certificateFields are the certificate fields selectable with Options.Fields:
+// the JSON names of Certificate, followed by virtual fields computed on output
+var certificateFields = []string{
+	"issuer_ca_id", "issuer_name", "issuer_org", "common_name", "name_value", "id",
+	"entry_timestamp", "not_before", "not_after", "serial_number", "nrd", "category", "queried_at", "implausible_dates",
+	// Virtual fields
+	"validity_days", "san_count", "crtsh_url", "apex",
+}
+
+// CertificateFields lists the field names accepted by Options.Fields
+func CertificateFields() []string {
+	return append([]string(nil), certificateFields...)
+}
+
+// ValidateFields returns an error naming the first unknown or repeated field
+func ValidateFields(fields []string) error {
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if seen[field] {
+			return fmt.Errorf("field %q is listed more than once", field)
+		}
+		seen[field] = true
+
+		known := false
+		for _, name := range certificateFields {
+			if field == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown field %q (available: %s)", field, strings.Join(certificateFields, ","))
+		}
+	}
+	return nil
+}
+
+// field returns the value of a real or virtual field of the certificate
+func (c Certificate) field(name string) interface{} {
+	switch name {
+	case "issuer_ca_id":
+		return c.IssuerCaID
+	case "issuer_name":
+		return c.IssuerName
+	case "issuer_org":
+		return c.IssuerOrg()
+	case "common_name":
+		return c.CommonName
+	case "name_value":
+		return c.NameValue
+	case "id":
+		return c.ID
+	case "entry_timestamp":
+		return c.EntryTimestamp
+	case "not_before":
+		return c.NotBefore
+	case "not_after":
+		return c.NotAfter
+	case "serial_number":
+		return c.SerialNumber
+	case "nrd":
+		return c.NewlyRegisteredDomain
+	case "category":
+		return c.Category
+	case "queried_at":
+		return c.QueriedAt
+	case "implausible_dates":
+		return c.ImplausibleDates
+	case "validity_days":
+		return int(c.NotAfter.Sub(c.NotBefore).Hours() / 24)
+	case "san_count":
+		return len(c.sanList())
+	case "crtsh_url":
+		return c.CrtShURL()
+	case "apex":
+		return ApexDomain(c.CommonName)
+	}
+	return nil
+}
+
+// csvTime formats a timestamp for CSV output, in UTC as RFC3339
+func csvTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// fieldString formats a field value for CSV output
+func fieldString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case time.Time:
+		return csvTime(v)
+	}
+	return fmt.Sprint(v)
+}
+
+// fieldRecord is a JSON object with its keys in the selected order
+type fieldRecord struct {
+	keys   []string
+	values []interface{}
+}
+
+func (f fieldRecord) MarshalJSON() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteByte('{')
+	for i, key := range f.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(f.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// selectFields projects the certificates onto the fields of o.Fields
+func (r Certificates) selectFields(o Options) []fieldRecord {
+	fields := o.Fields
+	res := make([]fieldRecord, 0, len(r))
+	for _, cert := range r {
+		record := fieldRecord{keys: fields, values: make([]interface{}, len(fields))}
+		for i, field := range fields {
+			record.values[i] = cert.field(field)
+			if id, ok := record.values[i].(int); ok && o.StringIDs && (field == "id" || field == "issuer_ca_id") {
+				record.values[i] = strconv.Itoa(id)
+			}
+		}
+		res = append(res, record)
+	}
+	return res
+}
@@ -0,0 +1,81 @@
+package result
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelectFields(t *testing.T) {
+	cert := sampleCerts[:1]
+
+	tests := []struct {
+		name   string
+		fields []string
+		json   string
+		csv    []string
+	}{
+		{"real fields", []string{"id", "common_name"},
+			`[{"id":12345678901,"common_name":"example.com"}]`,
+			[]string{"12345678901", "example.com"}},
+		{"virtual fields", []string{"validity_days", "san_count", "apex"},
+			`[{"validity_days":365,"san_count":2,"apex":"example.com"}]`,
+			[]string{"365", "2", "example.com"}},
+		{"mixed, in the given order", []string{"crtsh_url", "issuer_org", "not_after", "san_count"},
+			`[{"crtsh_url":"https://crt.sh/?id=12345678901","issuer_org":"DigiCert, Inc.","not_after":"2025-03-01T00:00:00Z","san_count":2}]`,
+			[]string{"https://crt.sh/?id=12345678901", "DigiCert, Inc.", "2025-03-01T00:00:00Z", "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := Options{Fields: tt.fields}
+			if err := ValidateFields(tt.fields); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := o.JSON(cert)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, data); err != nil {
+				t.Fatal(err)
+			}
+			if compact.String() != tt.json {
+				t.Errorf("JSON = %s, want %s", compact.String(), tt.json)
+			}
+
+			data, err = o.CSV(cert)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rows, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := [][]string{tt.fields, tt.csv}; !reflect.DeepEqual(rows, want) {
+				t.Errorf("CSV = %q, want %q", rows, want)
+			}
+		})
+	}
+}
+
+func TestValidateFields(t *testing.T) {
+	tests := []struct {
+		fields  []string
+		wantErr bool
+	}{
+		{[]string{"id", "apex"}, false},
+		{[]string{"id", "id"}, true},
+		{[]string{"id", "bogus"}, true},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateFields(tt.fields); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateFields(%v) = %v, want error %v", tt.fields, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package result
+
+import "regexp"
+
+// keepName reports whether name matches match (if set) and not exclude (if
+// set); exclude wins when both match
+func keepName(name string, match, exclude *regexp.Regexp) bool {
+	if exclude != nil && exclude.MatchString(name) {
+		return false
+	}
+	return match == nil || match.MatchString(name)
+}
+
+// FilterNames keeps the certificates with at least one name in NameValue
+// that passes match and exclude (see keepName)
+func (r Certificates) FilterNames(match, exclude *regexp.Regexp) Certificates {
+	res := make(Certificates, 0, len(r))
+	for _, cert := range r {
+		for _, name := range cert.sanList() {
+			if keepName(name, match, exclude) {
+				res = append(res, cert)
+				break
+			}
+		}
+	}
+	return res
+}
+
+// FilterNames keeps the subdomains whose name passes match and exclude (see
+// keepName)
+func (s Subdomains) FilterNames(match, exclude *regexp.Regexp) Subdomains {
+	res := make(Subdomains, 0, len(s))
+	for _, sub := range s {
+		if keepName(sub.Name, match, exclude) {
+			res = append(res, sub)
+		}
+	}
+	return res
+}
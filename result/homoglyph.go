@@ -0,0 +1,144 @@
+package result
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+var errInvalidPunycode = errors.New("invalid punycode")
+
+// scripts are the Unicode scripts considered when looking for mixed-script labels
+var scripts = []*unicode.RangeTable{
+	unicode.Latin, unicode.Cyrillic, unicode.Greek, unicode.Armenian,
+	unicode.Hebrew, unicode.Arabic, unicode.Han, unicode.Hiragana,
+	unicode.Katakana, unicode.Hangul, unicode.Thai, unicode.Devanagari,
+}
+
+// confusables are non-Latin letters that render (nearly) identical to Latin ones
+const confusables = "аАвВеЕһНіІјЈкКмМоОрРсСтТуухХѕЅԁԛԜԝӏҮүɡαΑβΒεΕηΗιΙκΚμΜνΝοΟρΡτΤυΥχΧζΖ"
+
+// IsSuspicious reports whether a hostname contains labels that mix Unicode
+// scripts, or are written entirely in look-alike non-Latin letters
+func IsSuspicious(name string) bool {
+	for _, label := range strings.Split(name, ".") {
+		if strings.HasPrefix(strings.ToLower(label), "xn--") {
+			decoded, err := decodePunycode(label[4:])
+			if err != nil {
+				continue
+			}
+			label = decoded
+		}
+		if suspiciousLabel(label) {
+			return true
+		}
+	}
+	return false
+}
+
+func suspiciousLabel(label string) bool {
+	seen := map[*unicode.RangeTable]bool{}
+	letters, lookalikes := 0, 0
+
+	for _, r := range label {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if strings.ContainsRune(confusables, r) {
+			lookalikes++
+		}
+		for _, script := range scripts {
+			if unicode.Is(script, r) {
+				seen[script] = true
+				break
+			}
+		}
+	}
+
+	if len(seen) > 1 {
+		return true
+	}
+	return letters > 0 && lookalikes == letters
+}
+
+// decodePunycode decodes the part of an IDNA A-label after "xn--" (RFC 3492)
+func decodePunycode(s string) (string, error) {
+	const (
+		base        = 36
+		tMin        = 1
+		tMax        = 26
+		skew        = 38
+		damp        = 700
+		initialBias = 72
+		initialN    = 128
+	)
+
+	var output []rune
+	if i := strings.LastIndexByte(s, '-'); i >= 0 {
+		output = []rune(s[:i])
+		s = s[i+1:]
+	}
+
+	adapt := func(delta, numPoints int, first bool) int {
+		if first {
+			delta /= damp
+		} else {
+			delta /= 2
+		}
+		delta += delta / numPoints
+		k := 0
+		for delta > ((base-tMin)*tMax)/2 {
+			delta /= base - tMin
+			k += base
+		}
+		return k + (base-tMin+1)*delta/(delta+skew)
+	}
+
+	n, i, bias := initialN, 0, initialBias
+	for pos := 0; pos < len(s); {
+		oldi, w := i, 1
+		for k := base; ; k += base {
+			if pos >= len(s) {
+				return "", errInvalidPunycode
+			}
+			digit, ok := punycodeDigit(s[pos])
+			pos++
+			if !ok {
+				return "", errInvalidPunycode
+			}
+			i += digit * w
+			t := k - bias
+			if t < tMin {
+				t = tMin
+			} else if t > tMax {
+				t = tMax
+			}
+			if digit < t {
+				break
+			}
+			w *= base - t
+		}
+		bias = adapt(i-oldi, len(output)+1, oldi == 0)
+		n += i / (len(output) + 1)
+		i %= len(output) + 1
+		if n > unicode.MaxRune {
+			return "", errInvalidPunycode
+		}
+		output = append(output[:i], append([]rune{rune(n)}, output[i:]...)...)
+		i++
+	}
+	return string(output), nil
+}
+
+func punycodeDigit(c byte) (int, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c-'0') + 26, true
+	case c >= 'a' && c <= 'z':
+		return int(c - 'a'), true
+	case c >= 'A' && c <= 'Z':
+		return int(c - 'A'), true
+	}
+	return 0, false
+}
@@ -0,0 +1,30 @@
+package result
+
+import "testing"
+
+func TestIsSuspicious(t *testing.T) {
+	tests := []struct {
+		name string
+		host string
+		want bool
+	}{
+		{"ascii", "www.apple.com", false},
+		{"latin idn", "xn--mnchen-3ya.de", false},
+		{"latin idn decoded", "münchen.de", false},
+		{"cyrillic a mixed in", "аpple.com", true},
+		{"cyrillic a mixed in, punycode", "xn--pple-43d.com", true},
+		{"all cyrillic look-alikes", "аррӏе.com", true},
+		{"all cyrillic look-alikes, punycode", "xn--80ak6aa92e.com", true},
+		{"spoofed subdomain label", "lоgin.example.com", true},
+		{"cyrillic word", "привет.ru", false},
+		{"invalid punycode", "xn--a!b.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsSuspicious(tt.host); got != tt.want {
+				t.Errorf("IsSuspicious(%q) = %v, want %v", tt.host, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package result
+
+import (
+	"bytes"
+	"fmt"
+)
+
+type HostEntry struct {
+	IP       string `json:"ip" yaml:"ip"`
+	Hostname string `json:"hostname" yaml:"hostname"`
+}
+
+// HostEntries renders resolved subdomains as /etc/hosts lines
+type HostEntries []HostEntry
+
+// HostEntries pairs each resolved subdomain with its first IP, skipping
+// subdomains that didn't resolve
+func (s Subdomains) HostEntries() HostEntries {
+	var res HostEntries
+	for _, sub := range s {
+		if len(sub.IPs) > 0 {
+			res = append(res, HostEntry{IP: sub.IPs[0], Hostname: sub.Name})
+		}
+	}
+	return res
+}
+
+// table renders the entries as plain "IP hostname" lines, ready to be
+// appended to /etc/hosts
+func (h HostEntries) table(o Options) []byte {
+	res := new(bytes.Buffer)
+	for _, entry := range h {
+		fmt.Fprintf(res, "%s\t%s\n", entry.IP, entry.Hostname)
+	}
+	return res.Bytes()
+}
+
+// cells returns the entries as a header and rows
+func (h HostEntries) cells(o Options) ([]string, [][]string) {
+	rows := make([][]string, 0, len(h))
+	for _, entry := range h {
+		rows = append(rows, []string{entry.IP, entry.Hostname})
+	}
+
+	return []string{"IP", "Hostname"}, rows
+}
+
+// csvCells returns the header and rows of CSV
+func (h HostEntries) csvCells(o Options) ([]string, [][]string) {
+	rows := make([][]string, 0, len(h))
+	for _, entry := range h {
+		rows = append(rows, []string{entry.IP, entry.Hostname})
+	}
+
+	return []string{"ip", "hostname"}, rows
+}
+
+func (h HostEntries) Table() []byte { return Options{}.Table(h) }
+
+func (h HostEntries) Markdown() []byte { return Options{}.Markdown(h) }
+
+func (h HostEntries) HTML() []byte { return Options{}.HTML(h) }
+
+func (h HostEntries) JSON() ([]byte, error) { return Options{}.JSON(h) }
+
+func (h HostEntries) YAML() ([]byte, error) { return Options{}.YAML(h) }
+
+func (h HostEntries) CSV() ([]byte, error) { return Options{}.CSV(h) }
+
+func (h HostEntries) TSV() ([]byte, error) { return Options{}.TSV(h) }
+
+func (h HostEntries) Size() int { return len(h) }
+
+func (h HostEntries) Merge(other Printer) Printer {
+	if o, ok := other.(HostEntries); ok {
+		return append(h, o...)
+	}
+	return h
+}
@@ -0,0 +1,28 @@
+package result
+
+import "testing"
+
+func TestHostEntriesTable(t *testing.T) {
+	tests := []struct {
+		name string
+		subs Subdomains
+		want string
+	}{
+		{"none", nil, ""},
+		{"resolved", Subdomains{{Name: "www.example.com", IPs: []string{"192.0.2.1"}}}, "192.0.2.1\twww.example.com\n"},
+		{"first IP only", Subdomains{{Name: "api.example.com", IPs: []string{"2001:db8::1", "192.0.2.2"}}}, "2001:db8::1\tapi.example.com\n"},
+		{"unresolved skipped", Subdomains{
+			{Name: "gone.example.com"},
+			{Name: "a.example.com", IPs: []string{"192.0.2.3"}},
+			{Name: "b.example.com", IPs: []string{"192.0.2.4"}},
+		}, "192.0.2.3\ta.example.com\n192.0.2.4\tb.example.com\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(Options{}.Table(tt.subs.HostEntries())); got != tt.want {
+				t.Errorf("hosts file = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package result
+
+import (
+	"bytes"
+	"html"
+	"html/template"
+)
+
+// HTMLSection is the table of one domain in an HTML report
+type HTMLSection struct {
+	Title string
+	Table []byte // Rendered by a printer's HTML method
+}
+
+// htmlTable renders header and rows as an HTML table whose columns sort when
+// their header is clicked; NRD cells are shown as a badge and crt.sh URLs as links
+func htmlTable(header []string, rows [][]string) []byte {
+	res := new(bytes.Buffer)
+	res.WriteString("<table>\n<thead><tr>")
+	for _, cell := range header {
+		res.WriteString("<th>" + html.EscapeString(cell) + "</th>")
+	}
+	res.WriteString("</tr></thead>\n<tbody>\n")
+
+	for _, row := range rows {
+		res.WriteString("<tr>")
+		for i, cell := range row {
+			value := html.EscapeString(cell)
+			if i < len(header) && header[i] == "NRD" && cell != "" {
+				value = `<span class="nrd">` + value + "</span>"
+			}
+			if i < len(header) && header[i] == "crt.sh" {
+				value = `<a href="` + value + `">` + value + "</a>"
+			}
+			res.WriteString("<td>" + value + "</td>")
+		}
+		res.WriteString("</tr>\n")
+	}
+
+	res.WriteString("</tbody>\n</table>\n")
+	return res.Bytes()
+}
+
+var htmlPage = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; white-space: pre-line; }
+th { background: #2b5797; color: #fff; cursor: pointer; user-select: none; }
+th.asc::after { content: " ▲"; }
+th.desc::after { content: " ▼"; }
+tr:nth-child(even) td { background: #f4f6f9; }
+.nrd { background: #d32f2f; color: #fff; border-radius: 4px; padding: 1px 6px; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{range .Sections}}<section>
+<h2>{{.Title}}</h2>
+{{.Table}}</section>
+{{end}}<script>
+document.querySelectorAll("table").forEach(function (table) {
+	table.querySelectorAll("th").forEach(function (th, col) {
+		th.addEventListener("click", function () {
+			var asc = !th.classList.contains("asc");
+			table.querySelectorAll("th").forEach(function (h) { h.classList.remove("asc", "desc"); });
+			th.classList.add(asc ? "asc" : "desc");
+			var body = table.tBodies[0];
+			Array.from(body.rows).sort(function (a, b) {
+				var x = a.cells[col].textContent, y = b.cells[col].textContent;
+				return (asc ? 1 : -1) * x.localeCompare(y, undefined, {numeric: true});
+			}).forEach(function (row) { body.appendChild(row); });
+		});
+	});
+});
+</script>
+</body>
+</html>
+`))
+
+// HTMLDocument renders the sections as a self-contained HTML page
+func HTMLDocument(title string, sections []HTMLSection) ([]byte, error) {
+	type section struct {
+		Title string
+		Table template.HTML
+	}
+	data := struct {
+		Title    string
+		Sections []section
+	}{Title: title}
+	for _, s := range sections {
+		// The tables are already escaped by htmlTable
+		data.Sections = append(data.Sections, section{Title: s.Title, Table: template.HTML(s.Table)})
+	}
+
+	res := new(bytes.Buffer)
+	if err := htmlPage.Execute(res, data); err != nil {
+		return nil, err
+	}
+	return res.Bytes(), nil
+}
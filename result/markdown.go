@@ -0,0 +1,35 @@
+package result
+
+import (
+	"bytes"
+	"strings"
+)
+
+// markdownEscaper keeps cell values from breaking a GitHub-flavored Markdown
+// table: pipes are escaped and line breaks become <br>
+var markdownEscaper = strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+
+// markdownTable renders header and rows as a GitHub-flavored Markdown table
+func markdownTable(header []string, rows [][]string) []byte {
+	res := new(bytes.Buffer)
+	writeMarkdownRow(res, header)
+
+	separator := make([]string, len(header))
+	for i := range separator {
+		separator[i] = "---"
+	}
+	writeMarkdownRow(res, separator)
+
+	for _, row := range rows {
+		writeMarkdownRow(res, row)
+	}
+	return res.Bytes()
+}
+
+func writeMarkdownRow(res *bytes.Buffer, cells []string) {
+	res.WriteString("|")
+	for _, cell := range cells {
+		res.WriteString(" " + markdownEscaper.Replace(cell) + " |")
+	}
+	res.WriteString("\n")
+}
@@ -0,0 +1,120 @@
+package result
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultNRDDays is the domain age below which a domain is likely newly
+// registered, used when Options.NRDDays is 0
+const DefaultNRDDays = 90
+
+// DefaultNRDMinResults is the number of certificates needed to tell whether
+// a domain is newly registered, used when Options.MinResults is 0
+const DefaultNRDMinResults = 3
+
+// IsNRD reports whether the certificates, as returned by a query limited to
+// limit results (0 = unlimited), belong to a likely newly registered domain:
+// one whose oldest certificate (by NotBefore) is younger than
+// Options.NRDDays. It also returns that age in days. Results that reached
+// the limit can't tell the age, as older certificates may be missing, and
+// neither can fewer than Options.MinResults certificates.
+func (r Certificates) IsNRD(o Options, limit int, now time.Time) (bool, int) {
+	if r.tooFewForNRD(o) {
+		return false, 0
+	}
+	return r.isYoung(limit, o, now)
+}
+
+// NRDUnsure reports whether the certificates would belong to a likely newly
+// registered domain, but are fewer than Options.MinResults to tell
+func (r Certificates) NRDUnsure(o Options, limit int, now time.Time) bool {
+	young, _ := r.isYoung(limit, o, now)
+	return young && r.tooFewForNRD(o)
+}
+
+func (r Certificates) tooFewForNRD(o Options) bool {
+	least := o.MinResults
+	if least == 0 {
+		least = DefaultNRDMinResults
+	}
+	return len(r) < least
+}
+
+// isYoung reports whether the oldest certificate is younger than
+// Options.NRDDays, and its age in days
+func (r Certificates) isYoung(limit int, o Options, now time.Time) (bool, int) {
+	if len(r) == 0 || (limit > 0 && len(r) >= limit) {
+		return false, 0
+	}
+
+	var oldest time.Time
+	for _, cert := range r {
+		if !plausibleDate(cert.NotBefore) {
+			continue
+		}
+		if oldest.IsZero() || cert.NotBefore.Before(oldest) {
+			oldest = cert.NotBefore
+		}
+	}
+	if oldest.IsZero() {
+		return false, 0
+	}
+
+	threshold := o.NRDDays
+	if threshold == 0 {
+		threshold = DefaultNRDDays
+	}
+
+	age := int(now.Sub(oldest).Hours() / 24)
+	return age < threshold, age
+}
+
+// MarkNRD sets NewlyRegisteredDomain of every certificate of each domain
+// (all certificates are one domain unless merged) that is likely newly
+// registered, reporting whether any is. The certificates must be those of a
+// query limited to limit results (0 = unlimited), before any filtering, and
+// are output with the verdict on the first certificate of each domain.
+func (r Certificates) MarkNRD(o Options, limit int) bool {
+	byDomain := make(map[string]Certificates)
+	var domains []string
+	for _, cert := range r {
+		if _, ok := byDomain[cert.Domain]; !ok {
+			domains = append(domains, cert.Domain)
+		}
+		byDomain[cert.Domain] = append(byDomain[cert.Domain], cert)
+	}
+
+	verdicts := make(map[string]string)
+	now := time.Now()
+	for _, domain := range domains {
+		if nrd, age := byDomain[domain].IsNRD(o, limit, now); nrd {
+			verdicts[domain] = fmt.Sprintf("likely (%dd old)", age)
+		}
+	}
+	for i := range r {
+		r[i].NewlyRegisteredDomain = verdicts[r[i].Domain]
+	}
+	return len(verdicts) > 0
+}
+
+// nrdOnce returns a copy of the certificates with the NewlyRegisteredDomain
+// verdict of MarkNRD only on the first certificate of each domain, reporting
+// whether any is marked
+func (r Certificates) nrdOnce() (Certificates, bool) {
+	res := make(Certificates, len(r))
+	seen := make(map[string]bool)
+	found := false
+	for i, cert := range r {
+		if cert.NewlyRegisteredDomain != "" {
+			if seen[cert.Domain] {
+				cert.NewlyRegisteredDomain = ""
+			} else {
+				seen[cert.Domain] = true
+				found = true
+			}
+		}
+		res[i] = cert
+	}
+	return res, found
+}
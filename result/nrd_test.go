@@ -0,0 +1,63 @@
+package result
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsNRDLimit(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	young := Certificates{
+		{ID: 1, NotBefore: now.AddDate(0, 0, -10)},
+		{ID: 2, NotBefore: now.AddDate(0, 0, -20)},
+		{ID: 3, NotBefore: now.AddDate(0, 0, -30)},
+	}
+
+	tests := []struct {
+		name  string
+		certs Certificates
+		limit int
+		want  bool
+	}{
+		{"unlimited", young, 0, true},
+		{"below the limit", young, 4, true},
+		{"reached the limit", young, 3, false},
+		{"per-domain limit above -l", young, 100, true},
+		{"too few", young[:2], 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := tt.certs.IsNRD(Options{}, tt.limit, now); got != tt.want {
+				t.Errorf("IsNRD(limit %d) = %v, want %v", tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkNRDOncePerDomain(t *testing.T) {
+	recent := time.Now().AddDate(0, 0, -5)
+	certs := Certificates{
+		{Domain: "new.com", ID: 1, NotBefore: recent},
+		{Domain: "old.com", ID: 2, NotBefore: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Domain: "new.com", ID: 3, NotBefore: recent},
+	}
+
+	if !certs.MarkNRD(Options{MinResults: 1}, 0) {
+		t.Fatal("MarkNRD found no newly registered domain")
+	}
+	for _, cert := range certs {
+		if got := cert.NewlyRegisteredDomain != ""; got != (cert.Domain == "new.com") {
+			t.Errorf("certificate %d of %s marked = %v", cert.ID, cert.Domain, got)
+		}
+	}
+
+	// Filtering out the first certificate keeps the verdict on the next one
+	shown, found := certs[1:].nrdOnce()
+	if !found || shown[0].NewlyRegisteredDomain != "" || shown[1].NewlyRegisteredDomain == "" {
+		t.Errorf("nrdOnce = %+v", shown)
+	}
+	if shown, _ := certs.nrdOnce(); shown[2].NewlyRegisteredDomain != "" {
+		t.Errorf("verdict repeated on certificate %d", shown[2].ID)
+	}
+}
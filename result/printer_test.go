@@ -0,0 +1,57 @@
+package result_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkgforge-security/crt/result"
+)
+
+// names is a Printer of another package
+type names []string
+
+func (n names) Table() []byte                       { return []byte("table") }
+func (n names) Markdown() []byte                    { return []byte("markdown") }
+func (n names) HTML() []byte                        { return []byte("html") }
+func (n names) JSON() ([]byte, error)               { return []byte("json"), nil }
+func (n names) YAML() ([]byte, error)               { return []byte("yaml"), nil }
+func (n names) TSV() ([]byte, error)                { return []byte("tsv"), nil }
+func (n names) Size() int                           { return len(n) }
+func (n names) Merge(result.Printer) result.Printer { return n }
+
+func (n names) CSV() ([]byte, error) {
+	return []byte("name\n" + strings.Join(n, "\n") + "\n"), nil
+}
+
+func TestForeignPrinter(t *testing.T) {
+	p := names{"a.example.com", "b.example.com"}
+
+	tests := []struct {
+		name   string
+		render func(o result.Options) ([]byte, error)
+		want   string
+	}{
+		{"table", func(o result.Options) ([]byte, error) { return o.Table(p), nil }, "table"},
+		{"markdown", func(o result.Options) ([]byte, error) { return o.Markdown(p), nil }, "markdown"},
+		{"html", func(o result.Options) ([]byte, error) { return o.HTML(p), nil }, "html"},
+		{"json", func(o result.Options) ([]byte, error) { return o.JSON(p) }, "json"},
+		{"yaml", func(o result.Options) ([]byte, error) { return o.YAML(p) }, "yaml"},
+		{"csv", func(o result.Options) ([]byte, error) { return o.CSV(p) }, "name\na.example.com\nb.example.com\n"},
+		{"csv without header", func(o result.Options) ([]byte, error) {
+			return result.Options{NoHeader: true}.CSV(p)
+		}, "a.example.com\nb.example.com\n"},
+		{"tsv", func(o result.Options) ([]byte, error) { return o.TSV(p) }, "name\na.example.com\nb.example.com\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.render(result.Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,8 +1,265 @@
 package result
 
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// Printer is a set of results; its methods render them with the default
+// Options. The methods of Options render the printers of this package with
+// other settings, and those of other packages through their own methods
 type Printer interface {
 	Table() []byte
+	Markdown() []byte
+	HTML() []byte
 	JSON() ([]byte, error)
+	YAML() ([]byte, error)
 	CSV() ([]byte, error)
+	TSV() ([]byte, error)
 	Size() int
+
+	// Merge returns the results followed by those of other, which must be of
+	// the same type (results of another type are ignored)
+	Merge(other Printer) Printer
+}
+
+// cellPrinter is implemented by the printers of this package. The table
+// formats (Table, Markdown, HTML) are rendered from its cells, CSV and TSV
+// from its CSV cells, and JSON and YAML from the printer itself (or its
+// records)
+type cellPrinter interface {
+	Printer
+
+	// cells returns the header and rows of the table formats, without colors
+	cells(o Options) ([]string, [][]string)
+
+	// csvCells returns the header and rows of CSV and TSV
+	csvCells(o Options) ([]string, [][]string)
+}
+
+// tabler is implemented by printers whose table isn't just their cells
+type tabler interface {
+	table(o Options) []byte
+}
+
+// Table renders the results as a table for the terminal
+func (o Options) Table(p Printer) []byte {
+	if t, ok := p.(tabler); ok {
+		return t.table(o)
+	}
+	c, ok := p.(cellPrinter)
+	if !ok {
+		return p.Table()
+	}
+	header, rows := c.cells(o)
+	return o.renderTable(header, rows, false)
+}
+
+// Markdown renders the columns of Table as a GitHub-flavored Markdown table
+func (o Options) Markdown(p Printer) []byte {
+	c, ok := p.(cellPrinter)
+	if !ok {
+		return p.Markdown()
+	}
+	return markdownTable(c.cells(o))
+}
+
+// HTML renders the columns of Table as an HTML table
+func (o Options) HTML(p Printer) []byte {
+	c, ok := p.(cellPrinter)
+	if !ok {
+		return p.HTML()
+	}
+	return htmlTable(c.cells(o))
+}
+
+// JSON renders the results as an indented JSON array (or object, for diffs)
+func (o Options) JSON(p Printer) ([]byte, error) {
+	if _, ok := p.(cellPrinter); !ok {
+		return p.JSON()
+	}
+	res, err := json.MarshalIndent(o.records(p), "", "\t")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal results: %s", err)
+	}
+
+	return res, nil
+}
+
+// RecordWriter writes CSV or TSV rows, like csv.Writer
+type RecordWriter interface {
+	Write(record []string) error
+	Flush()
+	Error() error
+}
+
+// WriteCSV writes the CSV rows of the results to w, after the header row if
+// header is set; the results of many domains can share one writer and header.
+// The first row of the CSV method of printers of other packages is their header
+func (o Options) WriteCSV(w RecordWriter, p Printer, header bool) error {
+	headers, rows, err := o.csvCells(p)
+	if err != nil {
+		return err
+	}
+	if header {
+		if err := w.Write(headers); err != nil {
+			return fmt.Errorf("failed to write CSV headers: %s", err)
+		}
+	}
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV content: %s", err)
+		}
+	}
+	w.Flush()
+
+	return w.Error()
+}
+
+// csvCells returns the CSV cells of p, read back from its CSV method if it is
+// a printer of another package
+func (o Options) csvCells(p Printer) ([]string, [][]string, error) {
+	if c, ok := p.(cellPrinter); ok {
+		headers, rows := c.csvCells(o)
+		return headers, rows, nil
+	}
+	data, err := p.CSV()
+	if err != nil {
+		return nil, nil, err
+	}
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CSV results: %s", err)
+	}
+	if len(records) == 0 {
+		return nil, nil, nil
+	}
+	return records[0], records[1:], nil
+}
+
+// CSV renders the results as CSV, with a header row unless NoHeader is set
+func (o Options) CSV(p Printer) ([]byte, error) {
+	res := new(bytes.Buffer)
+	if err := o.WriteCSV(csv.NewWriter(res), p, !o.NoHeader); err != nil {
+		return nil, err
+	}
+	return res.Bytes(), nil
+}
+
+// TSV renders the columns of CSV as tab-separated values, without quoting
+func (o Options) TSV(p Printer) ([]byte, error) {
+	res := new(bytes.Buffer)
+	if err := o.WriteCSV(NewTSVWriter(res), p, !o.NoHeader); err != nil {
+		return nil, err
+	}
+	return res.Bytes(), nil
+}
+
+// DomainSetter is implemented by printers whose records can carry the domain
+// they were looked up for, shown when merging domains (Options.Domain)
+type DomainSetter interface {
+	SetDomain(domain string)
+}
+
+// Stamper is implemented by printers whose records can carry a queried_at time
+type Stamper interface {
+	Stamp(t time.Time)
+}
+
+// Options controls how printers render their results; the zero value renders
+// the default columns with colors
+type Options struct {
+	NoFooter   bool // Skip the table footer that repeats the header
+	NoColor    bool // Render tables without ANSI colors
+	Compact    bool // Render tables without borders, row lines, padding or footer
+	Tree       bool // Render subdomains as a label hierarchy instead of a table
+	Suspicious bool // Show the homoglyph/IDN spoofing flag for subdomains
+	Wildcard   bool // Show whether subdomains are wildcard names (*.example.com)
+	Categorize bool // Show the apex/subdomain category of certificates
+	Domain     bool // Show the looked up domain of each certificate or subdomain (merged results)
+	Verbose    bool // Add the ID and crt.sh URL columns to certificate tables
+	OwnCert    bool // Show whether subdomains have a dedicated certificate
+	IPs        bool // Show the resolved IPs of subdomains
+
+	RelativeTime bool     // Show when certificates were logged/expire relative to now (table only)
+	DaysLeft     bool     // Show the days until certificates expire (table only)
+	Fields       []string // Only output these certificate fields, in this order (CSV/JSON only)
+	QueriedAt    bool     // Add the queried_at column to CSV output
+	StringIDs    bool     // Serialize id and issuer_ca_id as JSON strings
+	SerialFormat string   // Normalize serial numbers to SerialHex or SerialColon (CSV/JSON)
+	SANSummary   int      // Summarize certificates with more SANs than this (0 = never)
+	NoHeader     bool     // Skip the header row of CSV output
+	NRDColumn    bool     // Always add the NRD column to CSV output, so it is the same for every domain
+	NRDDays      int      // Domain age in days below which it is likely newly registered (0 = DefaultNRDDays)
+	MinResults   int      // Number of certificates below which NRD isn't told (0 = DefaultNRDMinResults)
+}
+
+// columnColors are the colors of table columns by header; the others are white
+var columnColors = map[string]tablewriter.Colors{
+	"":           tablewriter.Color(tablewriter.FgHiRedColor), // +/- of diffs
+	"Matching":   tablewriter.Color(tablewriter.FgHiYellowColor),
+	"Subdomains": tablewriter.Color(tablewriter.FgHiYellowColor),
+	"Days Left":  tablewriter.Color(tablewriter.FgHiRedColor),
+	"Suspicious": tablewriter.Color(tablewriter.FgHiRedColor),
+	"NRD":        tablewriter.Color(tablewriter.FgHiRedColor),
+}
+
+// renderTable renders header and rows as a table with a blue header,
+// repeated below the rows if footer is set (tablewriter can only draw the
+// footer with borders, so Compact tables have none)
+func (o Options) renderTable(header []string, rows [][]string, footer bool) []byte {
+	footer = footer && !o.Compact
+	res := new(bytes.Buffer)
+	table := tablewriter.NewWriter(res)
+
+	headerColors := make([]tablewriter.Colors, len(header))
+	colors := make([]tablewriter.Colors, len(header))
+	for i, name := range header {
+		headerColors[i] = tablewriter.Color(tablewriter.FgHiBlueColor)
+		colors[i] = tablewriter.Color(tablewriter.FgWhiteColor)
+		if color, ok := columnColors[name]; ok {
+			colors[i] = color
+		}
+	}
+
+	table.SetHeader(header)
+	if footer {
+		table.SetFooter(header)
+	}
+	if !o.NoColor {
+		table.SetHeaderColor(headerColors...)
+		if footer {
+			table.SetFooterColor(headerColors...)
+		}
+		table.SetColumnColor(colors...)
+	}
+	table.AppendBulk(rows)
+
+	o.applyStyle(table)
+	table.Render()
+
+	return res.Bytes()
+}
+
+// applyStyle sets the table's lines and padding: a line between every row by
+// default, or a borderless, padding-free layout with Compact
+func (o Options) applyStyle(table *tablewriter.Table) {
+	if o.Compact {
+		table.SetBorder(false)
+		table.SetHeaderLine(false)
+		table.SetRowLine(false)
+		table.SetNoWhiteSpace(true)
+		table.SetTablePadding("  ")
+		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		return
+	}
+
+	table.SetRowLine(true)
+	table.SetRowSeparator("—")
 }
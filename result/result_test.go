@@ -0,0 +1,94 @@
+package result
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCSVNoHeader(t *testing.T) {
+	subs := Subdomains{{Name: "www.example.com"}, {Name: "api.example.com"}}
+
+	tests := []struct {
+		name    string
+		printer Printer
+		header  string
+		opts    Options
+		rows    int
+	}{
+		{"certificates", sampleCerts, "issuer_ca_id", Options{}, 3},
+		{"certificates without header", sampleCerts, "issuer_ca_id", Options{NoHeader: true}, 2},
+		{"subdomains", subs, "subdomain", Options{}, 3},
+		{"subdomains without header", subs, "subdomain", Options{NoHeader: true}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.opts.CSV(tt.printer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rows, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+			if err != nil {
+				t.Fatalf("invalid CSV: %v\n%s", err, data)
+			}
+			if len(rows) != tt.rows {
+				t.Fatalf("got %d rows, want %d:\n%s", len(rows), tt.rows, data)
+			}
+			if got := rows[0][0] == tt.header; got == tt.opts.NoHeader {
+				t.Errorf("header row = %v, want %v:\n%s", got, !tt.opts.NoHeader, data)
+			}
+		})
+	}
+}
+
+func TestCSVTimestamps(t *testing.T) {
+	now := time.Now() // With a monotonic clock reading
+	cert := sampleCerts[0]
+	cert.EntryTimestamp = now
+	cert.NotBefore = time.Date(2024, 3, 1, 9, 0, 0, 0, time.FixedZone("CET", 3600))
+
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"all columns", Options{}},
+		{"selected fields", Options{Fields: []string{"id", "entry_timestamp", "not_before", "not_after"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.opts.CSV(Certificates{cert})
+			if err != nil {
+				t.Fatal(err)
+			}
+			rows, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+			if err != nil || len(rows) != 2 {
+				t.Fatalf("want a header and a row, got %v:\n%s", err, data)
+			}
+
+			want := map[string]time.Time{
+				"entry_timestamp": cert.EntryTimestamp,
+				"not_before":      cert.NotBefore,
+				"not_after":       cert.NotAfter,
+			}
+			for i, column := range rows[0] {
+				stamp, ok := want[column]
+				if !ok {
+					continue
+				}
+				delete(want, column)
+				got, err := time.Parse(time.RFC3339, rows[1][i])
+				if err != nil {
+					t.Errorf("%s = %q does not parse: %v", column, rows[1][i], err)
+				} else if !got.Equal(stamp.Truncate(time.Second)) {
+					t.Errorf("%s = %s, want %s", column, got, stamp)
+				}
+			}
+			if len(want) != 0 {
+				t.Errorf("columns missing: %v", want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package result
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema returns the JSON Schema of the JSON output: an array of certificate
+// (or, with subdomain enumeration, subdomain) objects. It is generated from
+// the json tags of Certificate and Subdomain, so it can't drift from them.
+func (o Options) Schema() ([]byte, error) {
+	cert := reflect.TypeOf(Certificate{})
+	if o.StringIDs {
+		cert = reflect.TypeOf(certificateStringIDs{})
+	}
+
+	// records links every certificate to its crt.sh page, despite omitempty
+	certSchema := objectSchema(cert)
+	certSchema["required"] = append(certSchema["required"].([]string), "crtsh_url")
+
+	schema := map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       "crt results",
+		"description": "Certificates of a domain, or its subdomains",
+		"type":        "array",
+		"items": map[string]interface{}{
+			"anyOf": []interface{}{
+				map[string]string{"$ref": "#/$defs/Certificate"},
+				map[string]string{"$ref": "#/$defs/Subdomain"},
+			},
+		},
+		"$defs": map[string]interface{}{
+			"Certificate": certSchema,
+			"Subdomain":   objectSchema(reflect.TypeOf(Subdomain{})),
+		},
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// objectSchema describes the JSON object encoding/json makes of the struct
+// t: one property per tagged field (fields of embedded structs included, and
+// shadowed by the outer ones), required unless omitempty
+func objectSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	omitted := make(map[string]bool)
+	var names []string
+	addProperties(t, properties, omitted, &names)
+
+	required := []string{}
+	for _, name := range names {
+		if !omitted[name] {
+			required = append(required, name)
+		}
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// addProperties adds the properties of the fields of t to properties (and
+// their names, in order, to names), those of embedded structs first so that
+// the outer fields replace them
+func addProperties(t reflect.Type, properties map[string]interface{}, omitted map[string]bool, names *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		if field := t.Field(i); field.Anonymous {
+			addProperties(field.Type, properties, omitted, names)
+		}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous || !field.IsExported() {
+			continue
+		}
+		name, omitempty := jsonName(field)
+		if name == "-" {
+			continue
+		}
+		if _, ok := properties[name]; !ok {
+			*names = append(*names, name)
+		}
+		properties[name] = typeSchema(field.Type)
+		omitted[name] = omitempty
+	}
+}
+
+// jsonName returns the JSON key of field and whether it is omitted when empty
+func jsonName(field reflect.StructField) (string, bool) {
+	name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, strings.Contains(","+opts+",", ",omitempty,")
+}
+
+// typeSchema describes the JSON value of a field of type t
+func typeSchema(t reflect.Type) map[string]interface{} {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Struct:
+		return objectSchema(t)
+	}
+	return map[string]interface{}{"type": "string"}
+}
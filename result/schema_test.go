@@ -0,0 +1,126 @@
+package result
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// filled sets every exported field of the struct v points to to a non-zero
+// value, so that no field is omitted from its JSON
+func filled(v interface{}) {
+	value := reflect.ValueOf(v).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		switch {
+		case !value.Type().Field(i).IsExported():
+		case field.Type() == reflect.TypeOf(time.Time{}):
+			field.Set(reflect.ValueOf(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)))
+		case field.Kind() == reflect.String:
+			field.SetString("x")
+		case field.Kind() == reflect.Int:
+			field.SetInt(1)
+		case field.Kind() == reflect.Bool:
+			field.SetBool(true)
+		case field.Kind() == reflect.Slice:
+			field.Set(reflect.ValueOf([]string{"x"}))
+		}
+	}
+}
+
+// jsonType is the JSON Schema type of a decoded JSON value
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "integer"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+func TestSchemaMatchesStructs(t *testing.T) {
+	var cert Certificate
+	filled(&cert)
+	cert.IssuerName = sampleCerts[0].IssuerName // With an organization
+	var sub Subdomain
+	filled(&sub)
+
+	tests := []struct {
+		name    string
+		opts    Options
+		def     string
+		full    Printer
+		minimal Printer // With the omitempty fields left out
+	}{
+		{"certificate", Options{}, "Certificate", Certificates{cert}, Certificates{{}}},
+		{"certificate with string IDs", Options{StringIDs: true}, "Certificate", Certificates{cert}, Certificates{{}}},
+		{"subdomain", Options{}, "Subdomain", Subdomains{sub}, Subdomains{{}}},
+	}
+
+	keys := func(t *testing.T, o Options, p Printer) map[string]interface{} {
+		data, err := o.JSON(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var records []map[string]interface{}
+		if err := json.Unmarshal(data, &records); err != nil || len(records) != 1 {
+			t.Fatalf("want one JSON record, got %s: %v", data, err)
+		}
+		return records[0]
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.opts.Schema()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var schema struct {
+				Defs map[string]struct {
+					Properties map[string]struct {
+						Type string `json:"type"`
+					} `json:"properties"`
+					Required []string `json:"required"`
+				} `json:"$defs"`
+			}
+			if err := json.Unmarshal(data, &schema); err != nil {
+				t.Fatalf("invalid schema %s: %v", data, err)
+			}
+			def := schema.Defs[tt.def]
+
+			full := keys(t, tt.opts, tt.full)
+			for key, value := range full {
+				property, ok := def.Properties[key]
+				if !ok {
+					t.Errorf("%q is output but not in the schema", key)
+				} else if got := jsonType(value); got != property.Type {
+					t.Errorf("%q is output as %s, the schema says %s", key, got, property.Type)
+				}
+			}
+			for key := range def.Properties {
+				if _, ok := full[key]; !ok {
+					t.Errorf("%q is in the schema but never output", key)
+				}
+			}
+
+			minimal := keys(t, tt.opts, tt.minimal)
+			var got []string
+			for key := range minimal {
+				got = append(got, key)
+			}
+			sort.Strings(got)
+			want := append([]string(nil), def.Required...)
+			sort.Strings(want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("always output %v, schema requires %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package result
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultSortField is the field certificates are sorted by without -sort,
+// newest first, like crt.sh lists them
+const DefaultSortField = "entry_timestamp"
+
+// Sort orders the certificates in place by one of CertificateFields, with
+// equal certificates ordered by crt.sh ID, so repeated runs give the same
+// order. Sorting the slice itself, rather than a rendered view of it, keeps
+// every output format in the same order.
+func (r Certificates) Sort(field string, reverse bool) {
+	sort.SliceStable(r, func(i, j int) bool {
+		a, b := r[i], r[j]
+		if reverse {
+			a, b = b, a
+		}
+		if less, equal := compareField(a.field(field), b.field(field)); !equal {
+			return less
+		}
+		return a.ID < b.ID
+	})
+}
+
+// Sort orders the subdomains in place by name, ignoring case
+func (r Subdomains) Sort(reverse bool) {
+	sort.SliceStable(r, func(i, j int) bool {
+		a, b := r[i].Name, r[j].Name
+		if reverse {
+			a, b = b, a
+		}
+		if la, lb := strings.ToLower(a), strings.ToLower(b); la != lb {
+			return la < lb
+		}
+		return a < b
+	})
+}
+
+// compareField compares two values of the same field
+func compareField(a, b interface{}) (less, equal bool) {
+	switch a := a.(type) {
+	case int:
+		return a < b.(int), a == b.(int)
+	case time.Time:
+		return a.Before(b.(time.Time)), a.Equal(b.(time.Time))
+	case bool:
+		return !a && b.(bool), a == b.(bool)
+	}
+	sa, sb := fieldString(a), fieldString(b)
+	return sa < sb, sa == sb
+}
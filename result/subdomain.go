@@ -1,65 +1,215 @@
 package result
 
 import (
-	"bytes"
-	"encoding/csv"
-	"encoding/json"
-	"fmt"
-
-	"github.com/olekukonko/tablewriter"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Subdomain struct {
-	Name string `json:"subdomain"`
+	Domain     string   `json:"domain,omitempty" yaml:"domain,omitempty"`
+	Name       string   `json:"subdomain" yaml:"subdomain"`
+	Wildcard   bool     `json:"wildcard,omitempty" yaml:"wildcard,omitempty"`
+	Suspicious bool     `json:"suspicious,omitempty" yaml:"suspicious,omitempty"`
+	HasOwnCert bool     `json:"has_own_cert,omitempty" yaml:"has_own_cert,omitempty"`
+	Resolved   bool     `json:"resolved,omitempty" yaml:"resolved,omitempty"`
+	IPs        []string `json:"ips,omitempty" yaml:"ips,omitempty"`
+	QueriedAt  string   `json:"queried_at,omitempty" yaml:"queried_at,omitempty"`
 }
 
 type Subdomains []Subdomain
 
-func (s Subdomains) Table() []byte {
-	res := new(bytes.Buffer)
-	table := tablewriter.NewWriter(res)
+// SetDomain records domain as the looked up domain of every subdomain
+func (s Subdomains) SetDomain(domain string) {
+	for i := range s {
+		s[i].Domain = domain
+	}
+}
 
-	table.SetHeader([]string{"Subdomains"})
+// MarkWildcards flags the wildcard names (*.example.com)
+func (s Subdomains) MarkWildcards() {
+	for i := range s {
+		s[i].Wildcard = strings.HasPrefix(s[i].Name, "*.")
+	}
+}
 
-	table.SetHeaderColor(tablewriter.Color(tablewriter.FgHiBlueColor))
-	table.SetColumnColor(tablewriter.Color(tablewriter.FgHiYellowColor))
+// FilterWildcards keeps only the wildcard names (wildcards true) or only the
+// others (wildcards false)
+func (s Subdomains) FilterWildcards(wildcards bool) Subdomains {
+	res := make(Subdomains, 0, len(s))
+	for _, sub := range s {
+		if sub.Wildcard == wildcards {
+			res = append(res, sub)
+		}
+	}
+	return res
+}
 
+// FilterResolved keeps only the subdomains that resolved to an address
+func (s Subdomains) FilterResolved() Subdomains {
+	res := make(Subdomains, 0, len(s))
 	for _, sub := range s {
-		table.Append([]string{sub.Name})
+		if sub.Resolved {
+			res = append(res, sub)
+		}
 	}
+	return res
+}
+
+// MarkSuspicious flags subdomains that look like homoglyph/IDN spoofs
+func (s Subdomains) MarkSuspicious() {
+	for i := range s {
+		s[i].Suspicious = IsSuspicious(s[i].Name)
+	}
+}
 
-	table.SetRowLine(true)
-	table.SetRowSeparator("—")
-	table.Render()
+// MarkOwnCerts flags subdomains that are the common name of one of certs,
+// i.e. that have a dedicated certificate rather than only appearing as a SAN
+func (s Subdomains) MarkOwnCerts(certs Certificates) {
+	own := make(map[string]bool, len(certs))
+	for _, cert := range certs {
+		own[strings.ToLower(cert.CommonName)] = true
+	}
+	for i := range s {
+		s[i].HasOwnCert = own[strings.ToLower(s[i].Name)]
+	}
+}
 
-	return res.Bytes()
+// Stamp records t (in RFC3339) as the time the subdomains were queried
+func (s Subdomains) Stamp(t time.Time) {
+	for i := range s {
+		s[i].QueriedAt = t.UTC().Format(time.RFC3339)
+	}
 }
 
-func (s Subdomains) JSON() ([]byte, error) {
-	res, err := json.MarshalIndent(s, "", "\t")
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal results: %s", err)
+// table renders the cells as a table, or the label hierarchy with Options.Tree
+func (s Subdomains) table(o Options) []byte {
+	if o.Tree {
+		return s.Tree()
 	}
 
-	return res, nil
+	info, rows := s.cells(o)
+	return o.renderTable(info, rows, false)
 }
 
-func (s Subdomains) CSV() ([]byte, error) {
-	res := new(bytes.Buffer)
-	w := csv.NewWriter(res)
+// cells returns the header and rows of the table formats
+func (s Subdomains) cells(o Options) ([]string, [][]string) {
+	info := []string{"Subdomains"}
+	if o.Domain {
+		info = append([]string{"Domain"}, info...)
+	}
+	if o.Wildcard {
+		info = append(info, "Wildcard")
+	}
+	if o.Suspicious {
+		info = append(info, "Suspicious")
+	}
+	if o.OwnCert {
+		info = append(info, "Own Cert")
+	}
+	if o.IPs {
+		info = append(info, "IPs")
+	}
+
+	rows := make([][]string, 0, len(s))
+	for _, sub := range s {
+		row := []string{sub.Name}
+		if o.Domain {
+			row = append([]string{sub.Domain}, row...)
+		}
+		if o.Wildcard {
+			row = append(row, strconv.FormatBool(sub.Wildcard))
+		}
+		if o.Suspicious {
+			row = append(row, suspiciousMark(sub.Suspicious))
+		}
+		if o.OwnCert {
+			row = append(row, strconv.FormatBool(sub.HasOwnCert))
+		}
+		if o.IPs {
+			row = append(row, strings.Join(sub.IPs, "\n"))
+		}
+		rows = append(rows, row)
+	}
+
+	return info, rows
+}
 
-	if err := w.Write([]string{"subdomain"}); err != nil {
-		return nil, fmt.Errorf("failed to write CSV headers: %s", err)
+// csvCells returns the header and rows of CSV
+func (s Subdomains) csvCells(o Options) ([]string, [][]string) {
+	headers := []string{"subdomain"}
+	if o.Domain {
+		headers = append([]string{"domain"}, headers...)
+	}
+	if o.Wildcard {
+		headers = append(headers, "wildcard")
+	}
+	if o.Suspicious {
+		headers = append(headers, "suspicious")
+	}
+	if o.OwnCert {
+		headers = append(headers, "has_own_cert")
+	}
+	if o.IPs {
+		headers = append(headers, "ips")
+	}
+	if o.QueriedAt {
+		headers = append(headers, "queried_at")
 	}
 
+	rows := make([][]string, 0, len(s))
 	for _, sub := range s {
-		if err := w.Write([]string{sub.Name}); err != nil {
-			return nil, fmt.Errorf("failed to write CSV content: %s", err)
+		row := []string{sub.Name}
+		if o.Domain {
+			row = append([]string{sub.Domain}, row...)
+		}
+		if o.Wildcard {
+			row = append(row, strconv.FormatBool(sub.Wildcard))
+		}
+		if o.Suspicious {
+			row = append(row, strconv.FormatBool(sub.Suspicious))
+		}
+		if o.OwnCert {
+			row = append(row, strconv.FormatBool(sub.HasOwnCert))
+		}
+		if o.IPs {
+			row = append(row, strings.Join(sub.IPs, " "))
 		}
+		if o.QueriedAt {
+			row = append(row, sub.QueriedAt)
+		}
+		rows = append(rows, row)
 	}
-	w.Flush()
 
-	return res.Bytes(), nil
+	return headers, rows
 }
 
+func (s Subdomains) Table() []byte { return Options{}.Table(s) }
+
+func (s Subdomains) Markdown() []byte { return Options{}.Markdown(s) }
+
+func (s Subdomains) HTML() []byte { return Options{}.HTML(s) }
+
+func (s Subdomains) JSON() ([]byte, error) { return Options{}.JSON(s) }
+
+func (s Subdomains) YAML() ([]byte, error) { return Options{}.YAML(s) }
+
+func (s Subdomains) CSV() ([]byte, error) { return Options{}.CSV(s) }
+
+func (s Subdomains) TSV() ([]byte, error) { return Options{}.TSV(s) }
+
 func (s Subdomains) Size() int { return len(s) }
+
+func (s Subdomains) Merge(other Printer) Printer {
+	if o, ok := other.(Subdomains); ok {
+		return append(s, o...)
+	}
+	return s
+}
+
+func suspiciousMark(suspicious bool) string {
+	if suspicious {
+		return "⚠️"
+	}
+	return ""
+}
@@ -0,0 +1,35 @@
+package result
+
+import "testing"
+
+func TestMarkOwnCerts(t *testing.T) {
+	certs := Certificates{
+		{CommonName: "www.example.com", NameValue: "www.example.com\nexample.com"},
+		{CommonName: "API.example.com", NameValue: "api.example.com"},
+		{CommonName: "*.example.com", NameValue: "*.example.com\nshop.example.com"},
+	}
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"www.example.com", true},
+		{"api.example.com", true},
+		{"*.example.com", true},
+		{"example.com", false},      // Only a SAN of www's certificate
+		{"shop.example.com", false}, // Only a SAN of the wildcard's certificate
+		{"mail.example.com", false},
+	}
+
+	subs := make(Subdomains, len(tests))
+	for i, tt := range tests {
+		subs[i].Name = tt.name
+	}
+	subs.MarkOwnCerts(certs)
+
+	for i, tt := range tests {
+		if subs[i].HasOwnCert != tt.want {
+			t.Errorf("%s: HasOwnCert = %v, want %v", tt.name, subs[i].HasOwnCert, tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package result
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+)
+
+// treeNode is one label of a hostname in the subdomain tree
+type treeNode struct {
+	children map[string]*treeNode
+	terminal bool // The hostname ending at this label was enumerated itself
+}
+
+func newTreeNode() *treeNode {
+	return &treeNode{children: make(map[string]*treeNode)}
+}
+
+// Tree renders the subdomains as a hierarchy grouped by label, e.g.
+//
+//	example.com
+//	├── api
+//	│   └── v2
+//	└── www
+func (s Subdomains) Tree() []byte {
+	root := newTreeNode()
+	for _, sub := range s {
+		labels := strings.Split(strings.TrimSuffix(strings.ToLower(sub.Name), "."), ".")
+		node := root
+		for i := len(labels) - 1; i >= 0; i-- {
+			child, ok := node.children[labels[i]]
+			if !ok {
+				child = newTreeNode()
+				node.children[labels[i]] = child
+			}
+			node = child
+		}
+		node.terminal = true
+	}
+
+	res := new(bytes.Buffer)
+	for _, label := range sortedLabels(root) {
+		// Collapse the shared suffix (e.g. "com" -> "example") into one line
+		name, node := label, root.children[label]
+		for len(node.children) == 1 && !node.terminal {
+			for child, next := range node.children {
+				name, node = child+"."+name, next
+			}
+		}
+		res.WriteString(name + "\n")
+		writeTree(res, node, "")
+	}
+	return res.Bytes()
+}
+
+func writeTree(res *bytes.Buffer, node *treeNode, prefix string) {
+	labels := sortedLabels(node)
+	for i, label := range labels {
+		branch, indent := "├── ", "│   "
+		if i == len(labels)-1 {
+			branch, indent = "└── ", "    "
+		}
+		res.WriteString(prefix + branch + label + "\n")
+		writeTree(res, node.children[label], prefix+indent)
+	}
+}
+
+func sortedLabels(node *treeNode) []string {
+	labels := make([]string, 0, len(node.children))
+	for label := range node.children {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
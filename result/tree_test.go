@@ -0,0 +1,36 @@
+package result
+
+import "testing"
+
+func TestSubdomainsTree(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		want  string
+	}{
+		{"none", nil, ""},
+		{"apex only", []string{"example.com"}, "example.com\n"},
+		{"sample set", []string{"www.example.com", "v2.api.example.com", "API.example.com.", "example.com", "*.example.com"}, `example.com
+├── *
+├── api
+│   └── v2
+└── www
+`},
+		{"shared suffix collapsed", []string{"a.example.org", "b.example.net", "c.b.example.net"}, `b.example.net
+└── c
+a.example.org
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subs := make(Subdomains, len(tt.names))
+			for i, name := range tt.names {
+				subs[i].Name = name
+			}
+			if got := string(subs.Tree()); got != tt.want {
+				t.Errorf("tree =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package result
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// tsvEscaper keeps each value on its line and in its column
+var tsvEscaper = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ")
+
+// TSVWriter writes records as tab-separated values without quoting,
+// replacing tabs and line breaks inside values with spaces
+type TSVWriter struct {
+	w *bufio.Writer
+}
+
+// NewTSVWriter returns a TSVWriter that writes to w
+func NewTSVWriter(w io.Writer) *TSVWriter {
+	return &TSVWriter{w: bufio.NewWriter(w)}
+}
+
+// Write writes a single record; like csv.Writer, it is buffered until Flush
+func (t *TSVWriter) Write(record []string) error {
+	for i, value := range record {
+		if i > 0 {
+			t.w.WriteByte('\t')
+		}
+		t.w.WriteString(tsvEscaper.Replace(value))
+	}
+	return t.w.WriteByte('\n')
+}
+
+// Flush writes any buffered records to the underlying writer
+func (t *TSVWriter) Flush() {
+	t.w.Flush()
+}
+
+// Error reports any error of a previous Write or Flush
+func (t *TSVWriter) Error() error {
+	_, err := t.w.Write(nil)
+	return err
+}
@@ -0,0 +1,71 @@
+package result
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// recorder is implemented by printers whose JSON isn't just the printer
+// itself, e.g. with computed or selected fields
+type recorder interface {
+	records(o Options) interface{}
+}
+
+// records returns the value JSON and YAML encode
+func (o Options) records(p Printer) interface{} {
+	if r, ok := p.(recorder); ok {
+		return r.records(o)
+	}
+	return p
+}
+
+// YAML renders the same fields as JSON, in the same order, as a YAML document
+func (o Options) YAML(p Printer) ([]byte, error) {
+	if _, ok := p.(cellPrinter); !ok {
+		return p.YAML()
+	}
+	res := new(bytes.Buffer)
+	enc := yaml.NewEncoder(res)
+	enc.SetIndent(2)
+	if err := enc.Encode(o.records(p)); err != nil {
+		return nil, fmt.Errorf("failed to marshal results to YAML: %s", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to marshal results to YAML: %s", err)
+	}
+
+	return res.Bytes(), nil
+}
+
+// MarshalYAML keeps the selected fields in their order
+func (f fieldRecord) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for i, key := range f.keys {
+		value := new(yaml.Node)
+		if err := value.Encode(f.values[i]); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	}
+	return node, nil
+}
+
+// MarshalYAML encodes the certificate with its IDs as strings, in the place
+// of the numeric ones
+func (c certificateStringIDs) MarshalYAML() (interface{}, error) {
+	node := new(yaml.Node)
+	if err := node.Encode(c.plainCertificate); err != nil {
+		return nil, err
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		switch node.Content[i].Value {
+		case "issuer_ca_id":
+			node.Content[i+1].SetString(c.IssuerCaID)
+		case "id":
+			node.Content[i+1].SetString(c.ID)
+		}
+	}
+	return node, nil
+}
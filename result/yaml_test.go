@@ -0,0 +1,93 @@
+package result
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestYAMLRoundTrip(t *testing.T) {
+	logged := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	certs := Certificates{
+		{
+			IssuerCaID: 183267, IssuerName: `C=US, O="DigiCert, Inc.", CN=DigiCert TLS RSA SHA256 2020 CA1`,
+			CommonName: "example.com", NameValue: "example.com\nwww.example.com", ID: 12345678901,
+			EntryTimestamp: logged, NotBefore: logged, NotAfter: logged.AddDate(1, 0, 0), SerialNumber: "0123",
+		},
+		{
+			IssuerCaID: 1, IssuerName: "CN=yes", CommonName: "true", NameValue: "null: ~", ID: 2,
+			EntryTimestamp: logged, NotBefore: logged, NotAfter: logged, SerialNumber: "1e5", QueriedAt: "2024-03-01T12:30:00Z",
+		},
+	}
+
+	tests := []struct {
+		name string
+		p    Printer
+	}{
+		{"certificates", certs},
+		{"no certificates", Certificates{}},
+		{"subdomains", Subdomains{{Domain: "example.com", Name: "*.example.com", Wildcard: true, IPs: []string{"192.0.2.1", "2001:db8::1"}}, {Name: "on.example.com"}}},
+		{"expiry groups", ExpiryGroups{{Domain: "example.com", Group: "< 30d", Count: 3}}},
+		{"host entries", HostEntries{{IP: "192.0.2.1", Hostname: "www.example.com"}}},
+		{"diff", CertificateDiff{Added: certs[:1], Removed: certs[1:]}},
+	}
+
+	var o Options
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonData, err := o.JSON(tt.p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			yamlData, err := o.YAML(tt.p)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Decoding either output must give the same results
+			fromJSON := reflect.New(reflect.TypeOf(tt.p))
+			if err := json.Unmarshal(jsonData, fromJSON.Interface()); err != nil {
+				t.Fatal(err)
+			}
+			fromYAML := reflect.New(reflect.TypeOf(tt.p))
+			if err := yaml.Unmarshal(yamlData, fromYAML.Interface()); err != nil {
+				t.Fatalf("%v in\n%s", err, yamlData)
+			}
+
+			want, _ := json.Marshal(fromJSON.Interface())
+			got, _ := json.Marshal(fromYAML.Interface())
+			if !bytes.Equal(got, want) {
+				t.Errorf("YAML round trip = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestYAMLFieldOrder(t *testing.T) {
+	certs := Certificates{{ID: 42, IssuerCaID: 7, CommonName: "example.com"}}
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"selected fields", Options{Fields: []string{"id", "common_name"}}, "- id: 42\n  common_name: example.com\n"},
+		{"string ids", Options{Fields: []string{"issuer_ca_id", "id"}, StringIDs: true}, "- issuer_ca_id: \"7\"\n  id: \"42\"\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.opts.YAML(certs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("YAML = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}